@@ -2,9 +2,11 @@
 package account
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
 	"strconv"
-	"time"
 
 	"form3-interview/httpclient"
 	"form3-interview/models"
@@ -16,6 +18,8 @@ const accountCreateEndpoint = "/v1/organisation/accounts"
 type CreateRequest struct {
 	Data *Data `json:"version"`
 	Host string
+	// IdempotencyKey is sent as Idempotency-Key header. Create requests are retried only when it is set.
+	IdempotencyKey string
 }
 
 // Data wraps the account model in a Data object. Used for json conversion
@@ -28,28 +32,57 @@ type Data struct {
 // It returns and Account populated with some extra info after creation.
 // https://api-docs.form3.tech/api.html#organisation-accounts-create
 func CreateAccount(url string, request *CreateRequest) (*models.Account, error) {
+	return CreateAccountWithContext(context.Background(), url, request)
+}
 
-	var data Data
-
-	body, err := json.Marshal(request.Data)
+// CreateAccountWithContext works like CreateAccount but the call is bound to ctx.
+// Cancelling ctx or reaching its deadline aborts the request and any pending retry.
+func CreateAccountWithContext(ctx context.Context, url string, request *CreateRequest) (*models.Account, error) {
+	client, err := NewClient(WithBaseURL(url))
 	if err != nil {
 		return nil, err
 	}
 
-	var headers = map[string]string{
-		"Host":           request.Host,
-		"Date":           time.Now().String(),
-		"Accept":         "application/vnd.api+json",
-		"Content-Type":   "application/vnd.api+json",
-		"Content-Length": strconv.Itoa(len(body)),
+	return client.Create(ctx, request)
+}
+
+// Create call the endpoint to create a new account.
+// It needs a CreateRequest containing the data on the account to create.
+// It returns and Account populated with some extra info after creation.
+// When the client is created WithValidation an invalid account is refused before calling the API.
+// https://api-docs.form3.tech/api.html#organisation-accounts-create
+func (c *Client) Create(ctx context.Context, request *CreateRequest) (_ *models.Account, err error) {
+	var attributes []httpclient.Attribute
+	if request.Data != nil && request.Data.Account != nil {
+		attributes = append(attributes, httpclient.Attr("account.id", request.Data.Account.ID.String()))
 	}
+	ctx, end := c.startOperation(ctx, "create", attributes...)
+	defer func() { end(err) }()
 
-	client, err := httpclient.CreateHTTPClient(url + accountCreateEndpoint)
+	var data Data
+
+	if c.validate {
+		if request.Data == nil || request.Data.Account == nil {
+			return nil, errors.New("the account to create is missing")
+		}
+		if err := request.Data.Account.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := json.Marshal(request.Data)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := client.Post(headers, body)
+	headers := c.headers(request.Host)
+	headers["Content-Type"] = "application/vnd.api+json"
+	headers["Content-Length"] = strconv.Itoa(len(body))
+	if request.IdempotencyKey != "" {
+		headers[httpclient.IdempotencyKeyHeader] = request.IdempotencyKey
+	}
+
+	resp, err := c.http.Send(ctx, httpclient.Request{Method: http.MethodPost, Path: accountCreateEndpoint, Headers: headers, Body: body})
 	if err != nil {
 		return nil, err
 	}
@@ -1,11 +1,14 @@
 package account
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"form3-interview/httpclient"
 	"form3-interview/models"
 
 	"github.com/google/uuid"
@@ -75,6 +78,93 @@ func TestCreateAccountFailed(t *testing.T) {
 	}
 }
 
+func TestCreateAccountConflictReturnsAPIError(t *testing.T) {
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(409)
+		res.Write([]byte(`{"error_message":"Account cannot be created as it violates a duplicate constraint"}`))
+	}))
+	defer func() { testServer.Close() }()
+
+	var req CreateRequest
+	_, err := CreateAccount(testServer.URL, &req)
+
+	if !httpclient.IsConflict(err) {
+		t.Errorf("Response contains wrong error, got %v", err)
+	}
+
+	var apiError *httpclient.APIError
+	if errors.As(err, &apiError) && apiError.ErrorMessage != "Account cannot be created as it violates a duplicate constraint" {
+		t.Errorf("Response contains wrong error message, got %v", apiError.ErrorMessage)
+	}
+}
+
+func TestCreateAccountRetriedOnlyWithIdempotencyKey(t *testing.T) {
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		callCount = callCount + 1
+		res.WriteHeader(503)
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(
+		WithBaseURL(testServer.URL),
+		WithRetryPolicy(httpclient.RetryPolicy{MaxAttempts: 3}),
+	)
+
+	var req CreateRequest
+	client.Create(context.Background(), &req)
+	if callCount != 1 {
+		t.Errorf("Create request without idempotency key retried: got %v calls expected %v", callCount, 1)
+	}
+
+	callCount = 0
+	req.IdempotencyKey = uuid.New().String()
+	client.Create(context.Background(), &req)
+	if callCount != 3 {
+		t.Errorf("Create request with idempotency key not retried: got %v calls expected %v", callCount, 3)
+	}
+}
+
+func TestCreateAccountWithValidationRefusesInvalidAccount(t *testing.T) {
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		callCount = callCount + 1
+		res.WriteHeader(201)
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL), WithValidation())
+
+	var newAccount models.Account
+	newAccount.Type = "accounts"
+	newAccount.ID = uuid.New()
+	newAccount.OrganisationID = uuid.New()
+	newAccount.Attributes = &models.AccountAttributes{Country: "UK", Bic: "NWBK"}
+
+	var req CreateRequest
+	req.Data = &Data{Account: &newAccount}
+
+	_, err := client.Create(context.Background(), &req)
+
+	var validationErrors models.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+	if len(validationErrors) != 2 {
+		t.Errorf("Expected 2 invalid fields, got %v", err)
+	}
+	if callCount != 0 {
+		t.Errorf("Invalid account sent to the API: got %v calls", callCount)
+	}
+
+	newAccount.Attributes = &models.AccountAttributes{Country: "GB", BankID: "400300", BankIDCode: "GBDSC", Bic: "NWBKGB22"}
+	_, err = client.Create(context.Background(), &req)
+	if err != nil || callCount != 1 {
+		t.Errorf("Valid account not sent to the API: got %v calls and error %v", callCount, err)
+	}
+}
+
 func TestCreateAccountBadURI(t *testing.T) {
 	var req CreateRequest
 
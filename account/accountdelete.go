@@ -2,8 +2,9 @@
 package account
 
 import (
+	"context"
+	"net/http"
 	"strconv"
-	"time"
 
 	"form3-interview/httpclient"
 
@@ -22,25 +23,33 @@ type DeleteRequest struct {
 // It returns an error if the operation fails
 // https://api-docs.form3.tech/api.html#organisation-accounts-delete
 func DeleteAccount(url string, request *DeleteRequest) error {
+	return DeleteAccountWithContext(context.Background(), url, request)
+}
 
-	var headers = map[string]string{
-		"Host":   request.Host,
-		"Date":   time.Now().String(),
-		"Accept": "application/vnd.api+json",
-	}
-
-	client, err := httpclient.CreateHTTPClient(url + accountEndpoint + request.AccountID.String())
+// DeleteAccountWithContext works like DeleteAccount but the call is bound to ctx.
+// Cancelling ctx or reaching its deadline aborts the request and any pending retry.
+func DeleteAccountWithContext(ctx context.Context, url string, request *DeleteRequest) error {
+	client, err := NewClient(WithBaseURL(url))
 	if err != nil {
 		return err
 	}
 
+	return client.Delete(ctx, request)
+}
+
+// Delete call the endpoint to delete an existing account.
+// It needs and DeleteRequest containing the Account ID and the version of the account
+// It returns an error if the operation fails
+// https://api-docs.form3.tech/api.html#organisation-accounts-delete
+func (c *Client) Delete(ctx context.Context, request *DeleteRequest) (err error) {
+	ctx, end := c.startOperation(ctx, "delete", httpclient.Attr("account.id", request.AccountID.String()), httpclient.Attr("account.version", request.Version))
+	defer func() { end(err) }()
+
+	headers := c.headers(request.Host)
+
 	queryParams := make(map[string]string)
 	queryParams["version"] = strconv.Itoa(request.Version)
 
-	err = client.Delete(headers, queryParams)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// the account is deleted only when the API answers 204 No Content
+	return c.http.SendNoContent(ctx, httpclient.Request{Method: http.MethodDelete, Path: accountEndpoint + request.AccountID.String(), Query: queryParams, Headers: headers})
 }
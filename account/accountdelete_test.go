@@ -1,10 +1,14 @@
 package account
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"form3-interview/httpclient"
+
 	"github.com/google/uuid"
 )
 
@@ -46,6 +50,27 @@ func TestDeleteAccountFailed(t *testing.T) {
 	}
 }
 
+func TestDeleteAccountUnexpectedStatus(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(200)
+	}))
+	defer func() { testServer.Close() }()
+
+	var req DeleteRequest
+	req.AccountID = uuid.New()
+
+	err := DeleteAccount(testServer.URL, &req)
+	var apiError *httpclient.APIError
+	if !errors.As(err, &apiError) || apiError.StatusCode != 200 {
+		t.Errorf("Expected the 200 to be rejected but got %v", err)
+	}
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+	if err := client.Delete(context.Background(), &req); err == nil {
+		t.Errorf("Expected the client to reject the 200 like DeleteAccount")
+	}
+}
+
 func TestDeleteAccountInvalidUrl(t *testing.T) {
 	var req DeleteRequest
 	err := DeleteAccount("http//foo", &req)
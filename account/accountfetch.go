@@ -2,9 +2,10 @@
 package account
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"time"
+	"net/http"
 
 	"form3-interview/httpclient"
 	"form3-interview/models"
@@ -17,6 +18,8 @@ const accountEndpoint = "/v1/organisation/accounts/"
 // AccountResponse wraps the account model in a Data object.  Used for json conversion
 type AccountResponse struct {
 	Account models.Account `json:"data"`
+	Links   *Links         `json:"links,omitempty"`
+	Meta    *Meta          `json:"meta,omitempty"`
 }
 
 // FetchRequest contains the account ID to find and the host
@@ -31,31 +34,53 @@ type FetchRequest struct {
 // It returns an Account if the account ID matches a record in the database.
 // https://api-docs.form3.tech/api.html#organisation-accounts-fetch
 func GetAccount(url string, request *FetchRequest) (*models.Account, error) {
+	return GetAccountWithContext(context.Background(), url, request)
+}
+
+// GetAccountWithContext works like GetAccount but the call is bound to ctx.
+// Cancelling ctx or reaching its deadline aborts the request and any pending retry.
+func GetAccountWithContext(ctx context.Context, url string, request *FetchRequest) (*models.Account, error) {
+	client, err := NewClient(WithBaseURL(url))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Fetch(ctx, request)
+}
+
+// Fetch call the endpoint to fetch a single account.
+// It needs a FetchRequest containing the account ID that needs to be fetched.
+// It returns an Account if the account ID matches a record in the database.
+// https://api-docs.form3.tech/api.html#organisation-accounts-fetch
+func (c *Client) Fetch(ctx context.Context, request *FetchRequest) (*models.Account, error) {
+	accountResponse, err := c.FetchResponse(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountResponse.Account, nil
+}
+
+// FetchResponse works like Fetch but returns the whole response, with the links and the metadata
+func (c *Client) FetchResponse(ctx context.Context, request *FetchRequest) (_ *AccountResponse, err error) {
+	ctx, end := c.startOperation(ctx, "fetch", httpclient.Attr("account.id", request.AccountID.String()))
+	defer func() { end(err) }()
 
 	// this check is needed to avoid making this a call to get a list of accounts
 	if request.AccountID.String() == "" {
 		return nil, errors.New("AccountID is mandatory to fetch an account")
 	}
 
-	var headers = map[string]string{
-		"Host":   request.Host,
-		"Date":   time.Now().String(),
-		"Accept": "application/vnd.api+json",
-	}
+	headers := c.headers(request.Host)
 
 	var accountResponse AccountResponse
 
-	client, err := httpclient.CreateHTTPClient(url + accountEndpoint + request.AccountID.String())
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := client.Get(headers, nil)
+	resp, err := c.http.Send(ctx, httpclient.Request{Method: http.MethodGet, Path: accountEndpoint + request.AccountID.String(), Headers: headers})
 	if err != nil {
 		return nil, err
 	}
 
 	json.Unmarshal(resp, &accountResponse)
 
-	return &accountResponse.Account, err
+	return &accountResponse, err
 }
@@ -1,10 +1,13 @@
 package account
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -59,6 +62,26 @@ func TestGetAccountBadURI(t *testing.T) {
 	}
 }
 
+func TestGetAccountWithContextCancelled(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// hold the request until the client gives up on it
+		<-req.Context().Done()
+	}))
+	defer func() { testServer.Close() }()
+
+	var req FetchRequest
+	req.AccountID = uuid.New()
+	req.Host = "myapi.form3.com"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := GetAccountWithContext(ctx, testServer.URL, &req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Request is returning an unexpected error: got %v expected %v", err, context.DeadlineExceeded)
+	}
+}
+
 func getAccountMockedResponse(t *testing.T, fileName string) (string, *AccountResponse) {
 
 	body := readMockedResponseFromFile(t, fileName)
@@ -68,3 +91,31 @@ func getAccountMockedResponse(t *testing.T, fileName string) (string, *AccountRe
 
 	return body, &response
 }
+
+func TestFetchResponse(t *testing.T) {
+	expectedBody, _ := getAccountMockedResponse(t, "testJson/account.json")
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(expectedBody))
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+
+	resp, err := client.FetchResponse(context.Background(), &FetchRequest{AccountID: uuid.MustParse("ea6239c1-99e9-42b3-bca1-92f5c068da6b")})
+	if err != nil {
+		t.Fatalf("Request is returning an error: got %v", err.Error())
+	}
+
+	if resp.Links == nil || resp.Links.Self != "/v1/organisation/accounts/ea6239c1-99e9-42b3-bca1-92f5c068da6b" {
+		t.Errorf("Response contains wrong Links, got %+v", resp.Links)
+	}
+	createdOn := time.Date(2021, 5, 6, 9, 28, 13, 843000000, time.UTC)
+	if resp.Account.CreatedOn == nil || !resp.Account.CreatedOn.Equal(createdOn) || resp.Account.ModifiedOn == nil || !resp.Account.ModifiedOn.Equal(createdOn) {
+		t.Errorf("Response contains wrong timestamps, got %v and %v", resp.Account.CreatedOn, resp.Account.ModifiedOn)
+	}
+	relationships := resp.Account.Relationships
+	if relationships == nil || relationships.AccountEvents == nil || len(relationships.AccountEvents.Data) != 1 || relationships.AccountEvents.Data[0].Type != "account_events" {
+		t.Errorf("Response contains wrong Relationships, got %+v", relationships)
+	}
+}
@@ -2,10 +2,11 @@
 package account
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"form3-interview/httpclient"
 	"form3-interview/models"
@@ -14,6 +15,24 @@ import (
 // AccountList wraps an array of accounts in a Data object. Used for json conversion
 type AccountList struct {
 	Accounts []models.Account `json:"data"`
+	Links    *Links           `json:"links,omitempty"`
+	Meta     *Meta            `json:"meta,omitempty"`
+}
+
+// Links contains the links to navigate the pages of a list
+type Links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// Meta contains the metadata returned with a response, e.g. the number of accounts of a list.
+// The counts are zero when the API does not return them.
+type Meta struct {
+	Count int `json:"count,omitempty"`
+	Total int `json:"total,omitempty"`
 }
 
 const defaultPageNumber = 0
@@ -41,29 +60,54 @@ type ListRequest struct {
 // It returns a list of Accounts matching the filter specified in the request
 // https://api-docs.form3.tech/api.html#organisation-accounts-list
 func GetAccountList(url string, request *ListRequest) ([]models.Account, error) {
+	return GetAccountListWithContext(context.Background(), url, request)
+}
 
-	var headers = map[string]string{
-		"Host":   request.Host,
-		"Date":   time.Now().String(),
-		"Accept": "application/vnd.api+json",
+// GetAccountListWithContext works like GetAccountList but the call is bound to ctx.
+// Cancelling ctx or reaching its deadline aborts the request and any pending retry.
+func GetAccountListWithContext(ctx context.Context, url string, request *ListRequest) ([]models.Account, error) {
+	client, err := NewClient(WithBaseURL(url))
+	if err != nil {
+		return nil, err
 	}
 
-	queryParams := populateQueryParams(request)
+	return client.List(ctx, request)
+}
 
-	client, err := httpclient.CreateHTTPClient(url + accountListEndpoint)
+// List call the endpoint to fetch a list of accounts.
+// It needs a ListRequest containing the filters to apply to the list
+// It returns a list of Accounts matching the filter specified in the request
+// https://api-docs.form3.tech/api.html#organisation-accounts-list
+func (c *Client) List(ctx context.Context, request *ListRequest) ([]models.Account, error) {
+
+	accountlist, err := c.ListPage(ctx, request)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := client.Get(headers, queryParams)
+	return accountlist.Accounts, nil
+}
+
+// ListPage works like List but returns the whole page, with the links to the other pages and the metadata
+func (c *Client) ListPage(ctx context.Context, request *ListRequest) (_ *AccountList, err error) {
+	ctx, end := c.startOperation(ctx, "list", httpclient.Attr("page.number", request.PageNumber), httpclient.Attr("page.size", request.PageSize))
+	defer func() { end(err) }()
+
+	headers := c.headers(request.Host)
+
+	queryParams := populateQueryParams(request)
+
+	resp, err := c.http.Send(ctx, httpclient.Request{Method: http.MethodGet, Path: accountListEndpoint, Query: queryParams, Headers: headers})
 	if err != nil {
 		return nil, err
 	}
 
 	var accountlist AccountList
-	json.Unmarshal(resp, &accountlist)
+	if err := json.Unmarshal(resp, &accountlist); err != nil {
+		return nil, err
+	}
 
-	return accountlist.Accounts, err
+	return &accountlist, nil
 }
 
 func populateQueryParams(request *ListRequest) map[string]string {
@@ -74,7 +118,7 @@ func populateQueryParams(request *ListRequest) map[string]string {
 	}
 
 	if request.PageSize != defaultPageSize && request.PageSize != 0 {
-		queryParams["page[size]="] = strconv.Itoa(request.PageSize)
+		queryParams["page[size]"] = strconv.Itoa(request.PageSize)
 	}
 
 	if request.BankID != nil {
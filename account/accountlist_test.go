@@ -1,6 +1,7 @@
 package account
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -107,3 +108,34 @@ func getAccountListMockedResponse(t *testing.T, fileName string) (string, Accoun
 
 	return body, response
 }
+
+func TestListPage(t *testing.T) {
+	expectedBody, expectedResponse := getAccountListMockedResponse(t, "testJson/accountlist.json")
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(expectedBody))
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+
+	resp, err := client.ListPage(context.Background(), &ListRequest{})
+	if err != nil {
+		t.Fatalf("Request is returning an error: got %v", err.Error())
+	}
+
+	if len(resp.Accounts) != len(expectedResponse.Accounts) {
+		t.Errorf("Number of accounts returned is wrong: got %v expected %v", len(resp.Accounts), len(expectedResponse.Accounts))
+	}
+	if resp.Links == nil || *resp.Links != *expectedResponse.Links || resp.Links.Self == "" {
+		t.Errorf("Response contains wrong Links, got %+v expected %+v", resp.Links, expectedResponse.Links)
+	}
+	if resp.Meta == nil || resp.Meta.Count != 2 {
+		t.Errorf("Response contains wrong Meta, got %+v", resp.Meta)
+	}
+	for _, account := range resp.Accounts {
+		if account.CreatedOn == nil || account.ModifiedOn == nil {
+			t.Errorf("Account %v is missing its timestamps", account.ID)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package account provides methods for creating, retrieving or deleteing accounts.
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"form3-interview/httpclient"
+	"form3-interview/models"
+)
+
+// ErrStopStream can be returned by the function passed to Stream to stop the iteration without error
+var ErrStopStream = errors.New("stop streaming")
+
+// Stream calls fn with every account matching the filters of the request, page after page following the next links.
+// The accounts are decoded one by one from the response as fn consumes them,
+// so the memory used does not depend on the page size.
+// It stops at the first error, either from the API or returned by fn, and when WithMaxItems accounts have been streamed.
+func (c *Client) Stream(ctx context.Context, request *ListRequest, fn func(models.Account) error, opts ...PageOption) error {
+	config := newPageConfig(opts)
+	pageRequest := *request
+	count := 0
+
+	for {
+		remaining := 0
+		if config.maxItems > 0 {
+			remaining = config.maxItems - count
+		}
+
+		links, streamed, err := c.streamPage(ctx, &pageRequest, fn, remaining)
+		count = count + streamed
+		if errors.Is(err, ErrStopStream) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if streamed == 0 || links == nil || links.Next == "" || (config.maxItems > 0 && count >= config.maxItems) {
+			return nil
+		}
+
+		nextPage, ok := pageNumberFromLink(links.Next)
+		if !ok {
+			return errors.New("unable to follow the next link: " + links.Next)
+		}
+		pageRequest.PageNumber = nextPage
+	}
+}
+
+// streamPage decodes a page of accounts from the response body, calling fn with each of them.
+// A positive limit stops the page after limit accounts.
+// It returns the links of the page and the number of accounts streamed.
+func (c *Client) streamPage(ctx context.Context, request *ListRequest, fn func(models.Account) error, limit int) (_ *Links, _ int, err error) {
+	ctx, end := c.startOperation(ctx, "list", httpclient.Attr("page.number", request.PageNumber), httpclient.Attr("page.size", request.PageSize))
+	defer func() { end(err) }()
+
+	var links *Links
+	streamed := 0
+
+	err = c.http.Stream(ctx, httpclient.Request{Method: http.MethodGet, Path: accountListEndpoint, Query: populateQueryParams(request), Headers: c.headers(request.Host)}, func(body io.Reader) error {
+		decoder := json.NewDecoder(body)
+
+		if err := expectDelim(decoder, '{'); err != nil {
+			return err
+		}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+
+			switch key {
+			case "data":
+				token, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				// an empty list can come as null
+				if token == nil {
+					continue
+				}
+				if token != json.Delim('[') {
+					return fmt.Errorf("invalid account list: expected [ but got %v", token)
+				}
+				for decoder.More() {
+					if limit > 0 && streamed >= limit {
+						return nil
+					}
+
+					var account models.Account
+					if err := decoder.Decode(&account); err != nil {
+						return err
+					}
+					streamed = streamed + 1
+					if err := fn(account); err != nil {
+						return err
+					}
+				}
+				if err := expectDelim(decoder, ']'); err != nil {
+					return err
+				}
+			case "links":
+				if err := decoder.Decode(&links); err != nil {
+					return err
+				}
+			default:
+				var ignored json.RawMessage
+				if err := decoder.Decode(&ignored); err != nil {
+					return err
+				}
+			}
+		}
+		return expectDelim(decoder, '}')
+	})
+
+	return links, streamed, err
+}
+
+// expectDelim reads the next token of the decoder, which must be the delimiter
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("invalid account list: expected %v but got %v", delim, token)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+func TestStreamFollowsNextLinks(t *testing.T) {
+	testServer, accounts, callCount := newPagedTestServer(t, 25, false)
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+
+	var resp []models.Account
+	err := client.Stream(context.Background(), &ListRequest{PageSize: 10}, func(account models.Account) error {
+		resp = append(resp, account)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Request is returning an error: got %v", err.Error())
+	}
+
+	checkAccountIDs(t, resp, accounts)
+	if *callCount != 3 {
+		t.Errorf("Wrong number of pages fetched: got %v expected %v", *callCount, 3)
+	}
+}
+
+func TestStreamMaxItems(t *testing.T) {
+	testServer, accounts, callCount := newPagedTestServer(t, 25, false)
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+
+	var resp []models.Account
+	err := client.Stream(context.Background(), &ListRequest{PageSize: 10}, func(account models.Account) error {
+		resp = append(resp, account)
+		return nil
+	}, WithMaxItems(15))
+	if err != nil {
+		t.Fatalf("Request is returning an error: got %v", err.Error())
+	}
+
+	checkAccountIDs(t, resp, accounts[:15])
+	if *callCount != 2 {
+		t.Errorf("Wrong number of pages fetched: got %v expected %v", *callCount, 2)
+	}
+}
+
+func TestStreamStopsOnCallbackError(t *testing.T) {
+	testServer, _, callCount := newPagedTestServer(t, 25, false)
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+
+	count := 0
+	err := client.Stream(context.Background(), &ListRequest{PageSize: 10}, func(account models.Account) error {
+		count = count + 1
+		if count == 3 {
+			return ErrStopStream
+		}
+		return nil
+	})
+	if err != nil || count != 3 || *callCount != 1 {
+		t.Errorf("Expected ErrStopStream to stop the stream without error, got %v after %v accounts and %v pages", err, count, *callCount)
+	}
+
+	failure := errors.New("reconciliation failed")
+	err = client.Stream(context.Background(), &ListRequest{PageSize: 10}, func(account models.Account) error {
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the error of the callback, got %v", err)
+	}
+}
+
+func TestStreamDecodesAccountsOneByOne(t *testing.T) {
+	received := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		first, _ := json.Marshal(models.Account{ID: uuid.New(), Type: "accounts"})
+		fmt.Fprintf(res, `{"meta":{"total":2},"data":[%s`, first)
+		res.(http.Flusher).Flush()
+
+		// the rest of the page is only sent once the first account has been streamed
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+		}
+		second, _ := json.Marshal(models.Account{ID: uuid.New(), Type: "accounts"})
+		fmt.Fprintf(res, `,%s],"links":{"self":"/v1/organisation/accounts"}}`, second)
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+
+	count := 0
+	err := client.Stream(context.Background(), &ListRequest{}, func(account models.Account) error {
+		count = count + 1
+		if count == 1 {
+			close(received)
+		}
+		return nil
+	})
+	if err != nil || count != 2 {
+		t.Errorf("Expected 2 accounts, got %v and %v", count, err)
+	}
+	select {
+	case <-received:
+	default:
+		t.Errorf("Expected the first account before the end of the response")
+	}
+}
+
+func TestStreamInvalidBodies(t *testing.T) {
+	for body, valid := range map[string]bool{
+		`{"data":null}`:                    true,
+		`{"data":[],"links":{}}`:           true,
+		`{"data":{"id":"not a list"}}`:     false,
+		`[]`:                               false,
+		`{"data":[{"id":"not a uuid"}]}`:   false,
+		`{"data":[{"type":"accounts"}]`:    false,
+		`{"data":[{"type":"accounts"}]}  `: true,
+	} {
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte(body))
+		}))
+
+		client, _ := NewClient(WithBaseURL(testServer.URL))
+		err := client.Stream(context.Background(), &ListRequest{}, func(models.Account) error { return nil })
+		if valid && err != nil {
+			t.Errorf("%v: unexpected error %v", body, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("%v: expected an error", body)
+		}
+		testServer.Close()
+	}
+}
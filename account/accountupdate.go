@@ -0,0 +1,171 @@
+// Package account provides methods for creating, retrieving or deleteing accounts.
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"form3-interview/httpclient"
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrVersionConflict is matched by errors.Is when an update is rejected
+// because the account has been modified since the version sent in the request
+var ErrVersionConflict = errors.New("account version conflict")
+
+// VersionConflictError is returned when the version of the account to update is not the current one.
+// It wraps the APIError returned by the server.
+type VersionConflictError struct {
+	Account *models.Account
+	Err     error
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("account %v has been modified, version %v is not the current one: %v", e.Account.ID, e.Account.Version, e.Err)
+}
+
+// Unwrap returns the APIError returned by the server
+func (e *VersionConflictError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrVersionConflict) true
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// UpdateRequest contains the account to update and the host.
+// The account must have the ID, the current Version and the attributes to change.
+// The boolean attributes of the account are only sent when true, Flags sets them to false.
+type UpdateRequest struct {
+	Data  *Data
+	Flags *UpdateFlags
+	Host  string
+}
+
+// UpdateFlags contains the boolean attributes to change, the nil ones are left unchanged
+type UpdateFlags struct {
+	JointAccount          *bool
+	Switched              *bool
+	AccountMatchingOptOut *bool
+}
+
+// patchDocument is the body of an update: the id, type and version of the account and the attributes to change
+type patchDocument struct {
+	Data patchAccount `json:"data"`
+}
+
+type patchAccount struct {
+	Type       string           `json:"type"`
+	ID         uuid.UUID        `json:"id"`
+	Version    int              `json:"version"`
+	Attributes *patchAttributes `json:"attributes,omitempty"`
+}
+
+// patchAttributes sends the boolean attributes set, false included, in place of the ones of the account
+type patchAttributes struct {
+	*models.AccountAttributes
+	JointAccount          *bool `json:"joint_account,omitempty"`
+	Switched              *bool `json:"switched,omitempty"`
+	AccountMatchingOptOut *bool `json:"account_matching_opt_out,omitempty"`
+}
+
+// newPatchDocument returns the body of the update request
+func newPatchDocument(request *UpdateRequest) patchDocument {
+	account := request.Data.Account
+	document := patchDocument{Data: patchAccount{Type: account.Type, ID: account.ID, Version: account.Version}}
+
+	flags := UpdateFlags{}
+	if request.Flags != nil {
+		flags = *request.Flags
+	}
+	if account.Attributes == nil && flags == (UpdateFlags{}) {
+		return document
+	}
+
+	attributes := &patchAttributes{AccountAttributes: account.Attributes}
+	if attributes.AccountAttributes == nil {
+		attributes.AccountAttributes = &models.AccountAttributes{}
+	}
+	attributes.JointAccount = boolAttribute(flags.JointAccount, attributes.AccountAttributes.JointAccount)
+	attributes.Switched = boolAttribute(flags.Switched, attributes.AccountAttributes.Switched)
+	attributes.AccountMatchingOptOut = boolAttribute(flags.AccountMatchingOptOut, attributes.AccountAttributes.AccountMatchingOptOut)
+	document.Data.Attributes = attributes
+	return document
+}
+
+// boolAttribute returns the flag when set, else the attribute of the account when true
+func boolAttribute(flag *bool, attribute bool) *bool {
+	if flag != nil || !attribute {
+		return flag
+	}
+	return &attribute
+}
+
+// UpdateAccount call the endpoint to update an existing account.
+// It needs an UpdateRequest containing the account ID, its current version and the attributes to change.
+// It returns the updated Account or a VersionConflictError if the account has been modified in the meantime.
+// https://api-docs.form3.tech/api.html#organisation-accounts-patch
+func UpdateAccount(url string, request *UpdateRequest) (*models.Account, error) {
+	return UpdateAccountWithContext(context.Background(), url, request)
+}
+
+// UpdateAccountWithContext works like UpdateAccount but the call is bound to ctx.
+// Cancelling ctx or reaching its deadline aborts the request and any pending retry.
+func UpdateAccountWithContext(ctx context.Context, url string, request *UpdateRequest) (*models.Account, error) {
+	client, err := NewClient(WithBaseURL(url))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Update(ctx, request)
+}
+
+// Update call the endpoint to update an existing account.
+// It needs an UpdateRequest containing the account ID, its current version and the attributes to change.
+// It returns the updated Account or a VersionConflictError if the account has been modified in the meantime.
+// https://api-docs.form3.tech/api.html#organisation-accounts-patch
+func (c *Client) Update(ctx context.Context, request *UpdateRequest) (_ *models.Account, err error) {
+	var attributes []httpclient.Attribute
+	if request.Data != nil && request.Data.Account != nil {
+		attributes = append(attributes, httpclient.Attr("account.id", request.Data.Account.ID.String()), httpclient.Attr("account.version", request.Data.Account.Version))
+	}
+	ctx, end := c.startOperation(ctx, "update", attributes...)
+	defer func() { end(err) }()
+
+	if request.Data == nil || request.Data.Account == nil {
+		return nil, errors.New("Account is mandatory to update an account")
+	}
+	account := request.Data.Account
+
+	body, err := json.Marshal(newPatchDocument(request))
+	if err != nil {
+		return nil, err
+	}
+
+	headers := c.headers(request.Host)
+	headers["Content-Type"] = "application/vnd.api+json"
+	headers["Content-Length"] = strconv.Itoa(len(body))
+
+	resp, err := c.http.Send(ctx, httpclient.Request{Method: http.MethodPatch, Path: accountEndpoint + account.ID.String(), Headers: headers, Body: body})
+	if httpclient.IsConflict(err) {
+		return nil, &VersionConflictError{Account: account, Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data Data
+	err = json.Unmarshal(resp, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Account, nil
+}
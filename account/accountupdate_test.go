@@ -0,0 +1,149 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"form3-interview/httpclient"
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+func TestUpdateAccountOK(t *testing.T) {
+	expectedBody, expectedResponse := getCreateAccountMockedResponse(t, "testJson/account.json")
+
+	var sentData Data
+	var method, path string
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		method = req.Method
+		path = req.URL.Path
+		body, _ := ioutil.ReadAll(req.Body)
+		json.Unmarshal(body, &sentData)
+		res.WriteHeader(200)
+		res.Write([]byte(expectedBody))
+	}))
+	defer func() { testServer.Close() }()
+
+	var attributes models.AccountAttributes
+	attributes.BankAccountName = "Alessandro Lallo"
+	attributes.CustomerID = "Ref123"
+
+	var account models.Account
+	account.ID, _ = uuid.Parse("ea6239c1-99e9-42b3-bca1-92f5c068da6b")
+	account.Type = "accounts"
+	account.Version = 3
+	account.Attributes = &attributes
+
+	var req UpdateRequest
+	req.Host = "myapi.form3.com"
+	req.Data = &Data{Account: &account}
+
+	resp, err := UpdateAccount(testServer.URL, &req)
+	if err != nil {
+		t.Fatalf("Request is returning an error: got %v", err.Error())
+	}
+	CheckAccountResponse(t, resp, expectedResponse.Account)
+
+	if method != http.MethodPatch {
+		t.Errorf("Request sent with wrong method: got %v expected %v", method, http.MethodPatch)
+	}
+	if path != accountEndpoint+account.ID.String() {
+		t.Errorf("Request sent to wrong path: got %v expected %v", path, accountEndpoint+account.ID.String())
+	}
+	if sentData.Account == nil || sentData.Account.Version != 3 {
+		t.Errorf("Request sent without the current version of the account")
+	}
+}
+
+func TestUpdateAccountVersionConflict(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(409)
+		res.Write([]byte(`{"error_message":"invalid version"}`))
+	}))
+	defer func() { testServer.Close() }()
+
+	var account models.Account
+	account.ID = uuid.New()
+	account.Version = 1
+
+	var req UpdateRequest
+	req.Data = &Data{Account: &account}
+
+	_, err := UpdateAccount(testServer.URL, &req)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Request is returning an unexpected error: got %v expected %v", err, ErrVersionConflict)
+	}
+
+	var apiError *httpclient.APIError
+	if !errors.As(err, &apiError) || apiError.ErrorMessage != "invalid version" {
+		t.Errorf("Version conflict is not wrapping the APIError: got %v", err)
+	}
+}
+
+func TestUpdateAccountNotFound(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(404)
+	}))
+	defer func() { testServer.Close() }()
+
+	var req UpdateRequest
+	req.Data = &Data{Account: &models.Account{ID: uuid.New()}}
+
+	_, err := UpdateAccount(testServer.URL, &req)
+	if errors.Is(err, ErrVersionConflict) || !httpclient.IsNotFound(err) {
+		t.Errorf("Request is returning an unexpected error: got %v", err)
+	}
+}
+
+func TestUpdateAccountMissingAccount(t *testing.T) {
+	var req UpdateRequest
+	_, err := UpdateAccount("http://myapi.form3.com", &req)
+	if err == nil {
+		t.Errorf("Request without an account is not returning an error")
+	}
+}
+
+func TestUpdateAccountSendsOnlyTheChanges(t *testing.T) {
+	var sent map[string]map[string]interface{}
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		json.Unmarshal(body, &sent)
+		res.Write([]byte(`{"data":{}}`))
+	}))
+	defer func() { testServer.Close() }()
+
+	var account models.Account
+	account.ID = uuid.New()
+	account.Type = "accounts"
+	account.Version = 2
+	account.Attributes = &models.AccountAttributes{CustomerID: "Ref123", JointAccount: true}
+
+	notSwitched := false
+	var req UpdateRequest
+	req.Data = &Data{Account: &account}
+	req.Flags = &UpdateFlags{Switched: &notSwitched}
+
+	if _, err := UpdateAccount(testServer.URL, &req); err != nil {
+		t.Fatalf("Request is returning an error: got %v", err)
+	}
+
+	data := sent["data"]
+	if len(data) != 4 || data["id"] != account.ID.String() || data["type"] != "accounts" || data["version"] != float64(2) {
+		t.Fatalf("Expected only the id, type, version and attributes to be sent, got %v", data)
+	}
+	expected := map[string]interface{}{"customer_id": "Ref123", "joint_account": true, "switched": false}
+	attributes, _ := data["attributes"].(map[string]interface{})
+	if len(attributes) != len(expected) {
+		t.Errorf("Expected the attributes %v but got %v", expected, attributes)
+	}
+	for name, value := range expected {
+		if attributes[name] != value {
+			t.Errorf("Expected %v to be %v but got %v", name, value, attributes[name])
+		}
+	}
+}
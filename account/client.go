@@ -0,0 +1,241 @@
+// Package account provides methods for creating, retrieving or deleteing accounts.
+package account
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"form3-interview/httpclient"
+	"form3-interview/models"
+)
+
+// Client calls the account endpoints of the Form3 API.
+// It is created once through NewClient and its configuration is shared by every call.
+type Client struct {
+	baseURL     string
+	host        string
+	userAgent   string
+	httpClient  httpclient.HttpClient
+	retryPolicy *httpclient.RetryPolicy
+	breaker     *httpclient.CircuitBreaker
+	limiter     *httpclient.RateLimiter
+	signer      httpclient.Signer
+	authorizer  httpclient.Authorizer
+	middlewares []httpclient.Middleware
+	logger      *httpclient.RequestLogger
+	metrics     httpclient.Metrics
+	tracer      httpclient.Tracer
+	validate    bool
+
+	// http sends the requests of every call
+	http *httpclient.Client
+}
+
+// Option configures a Client created with NewClient
+type Option func(*Client)
+
+// WithBaseURL sets the base url of the API, e.g. http://localhost:8080
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHost sets the Host header sent when a request does not specify one
+func WithHost(host string) Option {
+	return func(c *Client) {
+		c.host = host
+	}
+}
+
+// WithHTTPClient sets the implementation used to send the http requests.
+// It can be used to share a transport or to set custom timeouts.
+func WithHTTPClient(httpClient httpclient.HttpClient) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests
+func WithRetryPolicy(policy httpclient.RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = &policy
+	}
+}
+
+// WithRequestLogger logs every attempt of the requests with the logger.
+// The personal data of the accounts, listed by models.PersonalDataFields, is redacted from the logged bodies.
+func WithRequestLogger(logger httpclient.RequestLogger) Option {
+	return func(c *Client) {
+		logger.Redact = append(append([]string{}, models.PersonalDataFields...), logger.Redact...)
+		c.logger = &logger
+	}
+}
+
+// WithMetrics measures the requests, labelled with the operation: create, fetch, list, update or delete.
+// httpclient.PrometheusMetrics exports them in the Prometheus format.
+func WithMetrics(metrics httpclient.Metrics) Option {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
+
+// WithTracer traces every operation and the attempts of its requests.
+// The spans of the attempts are propagated to the API through the traceparent header.
+func WithTracer(tracer httpclient.Tracer) Option {
+	return func(c *Client) {
+		c.tracer = tracer
+	}
+}
+
+// WithValidation makes Create validate the account before sending it.
+// An invalid account is refused with the models.ValidationErrors returned by Validate.
+func WithValidation() Option {
+	return func(c *Client) {
+		c.validate = true
+	}
+}
+
+// WithCircuitBreaker makes the client fail fast while the API keeps failing.
+// The breaker can be shared by several clients calling the same hosts.
+func WithCircuitBreaker(breaker *httpclient.CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// WithRateLimiter delays the requests exceeding the rate of the API rather than getting 429 responses.
+// The limiter can be shared by several clients calling the same hosts.
+func WithRateLimiter(limiter *httpclient.RateLimiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// WithSigner authenticates every request with the signer, e.g. an httpclient.HTTPSigner for the production API
+func WithSigner(signer httpclient.Signer) Option {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// WithClientCredentials authenticates the requests with the bearer tokens of an OAuth2 client credentials grant.
+// The tokens are cached until they are about to expire and replaced when the API refuses them.
+// It can't be combined with WithSigner, both set the Authorization header.
+func WithClientCredentials(tokenURL string, clientID string, clientSecret string, scopes ...string) Option {
+	return WithAuthorizer(httpclient.NewClientCredentials(tokenURL, clientID, clientSecret, scopes...))
+}
+
+// WithAuthorizer adds the credentials of the authorizer to every request
+func WithAuthorizer(authorizer httpclient.Authorizer) Option {
+	return func(c *Client) {
+		c.authorizer = authorizer
+	}
+}
+
+// WithMiddleware adds middlewares to the chain wrapping every request, the first one being the outermost
+func WithMiddleware(middlewares ...httpclient.Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// NewClient creates a Client configured with the options passed through.
+// It returns an error if the base url is not valid or if both a signer and an authorizer are set.
+// The Client is safe for concurrent use, its calls share the same connections.
+func NewClient(opts ...Option) (*Client, error) {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	_, err := url.ParseRequestURI(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if c.signer != nil && c.authorizer != nil {
+		return nil, errors.New("the requests can be either signed or authorized, not both")
+	}
+
+	c.http, err = c.newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// newHTTPClient creates the httpclient shared by the calls of the client
+func (c *Client) newHTTPClient() (*httpclient.Client, error) {
+	client, err := httpclient.CreateHTTPClient(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.httpClient != nil {
+		client.HTTPClient = c.httpClient
+	}
+	client.RetryPolicy = c.retryPolicy
+	client.CircuitBreaker = c.breaker
+	client.RateLimiter = c.limiter
+	client.Authorizer = c.authorizer
+	client.Signer = c.signer
+	client.RequestLogger = c.logger
+	client.Metrics = c.metrics
+	client.Tracer = c.tracer
+	client.Use(c.middlewares...)
+
+	return client, nil
+}
+
+// startOperation names the operation in the context of its requests and starts its span when the client has a tracer.
+// The function returned ends the span with the error of the operation.
+func (c *Client) startOperation(ctx context.Context, operation string, attributes ...httpclient.Attribute) (context.Context, func(error)) {
+	ctx = httpclient.WithOperation(ctx, operation)
+	if c.tracer == nil {
+		return ctx, func(error) {}
+	}
+
+	attributes = append([]httpclient.Attribute{httpclient.Attr("operation", operation)}, attributes...)
+	ctx, span := c.tracer.Start(ctx, "account."+operation, attributes...)
+	return ctx, func(err error) {
+		var apiError *httpclient.APIError
+		if errors.As(err, &apiError) {
+			span.SetAttributes(httpclient.Attr("http.response.status_code", apiError.StatusCode))
+		}
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+	}
+}
+
+// headers returns the headers common to every request.
+// The host of the request takes precedence over the one of the client.
+func (c *Client) headers(host string) map[string]string {
+	if host == "" {
+		host = c.host
+	}
+
+	var headers = map[string]string{
+		"Host":   host,
+		"Date":   time.Now().UTC().Format(http.TimeFormat),
+		"Accept": "application/vnd.api+json",
+	}
+
+	if c.userAgent != "" {
+		headers["User-Agent"] = c.userAgent
+	}
+
+	return headers
+}
@@ -0,0 +1,266 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"form3-interview/httpclient"
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+func TestNewClientInvalidURL(t *testing.T) {
+	client, err := NewClient(WithBaseURL("http//foo"))
+	if err == nil || client != nil {
+		t.Errorf("Client created with an invalid base URL")
+	}
+}
+
+func TestNewClientSignerAndAuthorizer(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(
+		WithBaseURL("http://localhost:8080"),
+		WithSigner(httpclient.NewHTTPSigner("key-id", privateKey)),
+		WithClientCredentials("http://localhost:8080/oauth2/token", "client", "s3cret"),
+	)
+	if err == nil || client != nil {
+		t.Errorf("Client created with both a signer and client credentials")
+	}
+}
+
+func TestClientSendsConfiguredHeaders(t *testing.T) {
+	var userAgent, host, date string
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		userAgent = req.Header.Get("User-Agent")
+		host = req.Host
+		date = req.Header.Get("Date")
+		res.WriteHeader(204)
+	}))
+	defer func() { testServer.Close() }()
+
+	client, err := NewClient(
+		WithBaseURL(testServer.URL),
+		WithUserAgent("form3-client-test"),
+		WithHost("api.form3.tech"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create the client: got %v", err.Error())
+	}
+
+	var req DeleteRequest
+	req.AccountID = uuid.New()
+
+	err = client.Delete(context.Background(), &req)
+	if err != nil {
+		t.Errorf("Request is returning an error: got %v", err.Error())
+	}
+	if userAgent != "form3-client-test" {
+		t.Errorf("Request sent with wrong User-Agent: got %v expected %v", userAgent, "form3-client-test")
+	}
+	if host != "api.form3.tech" {
+		t.Errorf("Request sent with wrong Host: got %v expected %v", host, "api.form3.tech")
+	}
+	if _, err := time.Parse(http.TimeFormat, date); err != nil {
+		t.Errorf("Request sent with a Date not in RFC 1123 format: got %v", date)
+	}
+}
+
+func TestClientSignsRequests(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := &httpclient.Verifier{Keys: map[string]*rsa.PublicKey{"key-id": &privateKey.PublicKey}}
+
+	var verifyErr error
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		verifyErr = verifier.Verify(req, body)
+		res.WriteHeader(201)
+		res.Write([]byte(`{"data":{}}`))
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(
+		WithBaseURL(testServer.URL),
+		WithHost("api.form3.tech"),
+		WithSigner(httpclient.NewHTTPSigner("key-id", privateKey)),
+	)
+
+	var req CreateRequest
+	req.Data = &Data{Account: &models.Account{Type: "accounts", ID: uuid.New()}}
+	if _, err := client.Create(context.Background(), &req); err != nil {
+		t.Fatalf("Request is returning an error: got %v", err)
+	}
+	if verifyErr != nil {
+		t.Errorf("Request not signed correctly: got %v", verifyErr)
+	}
+}
+
+func TestClientUsesMiddlewares(t *testing.T) {
+	var requestID string
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requestID = req.Header.Get(httpclient.RequestIDHeader)
+		res.WriteHeader(204)
+	}))
+	defer func() { testServer.Close() }()
+
+	var seen []string
+	client, _ := NewClient(
+		WithBaseURL(testServer.URL),
+		WithMiddleware(httpclient.RequestID()),
+		WithMiddleware(func(next httpclient.Doer) httpclient.Doer {
+			return httpclient.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				seen = append(seen, req.Method+" "+req.Header.Get(httpclient.RequestIDHeader))
+				return next.Do(req)
+			})
+		}),
+	)
+
+	if err := client.Delete(context.Background(), &DeleteRequest{AccountID: uuid.New()}); err != nil {
+		t.Fatalf("Request is returning an error: got %v", err)
+	}
+	if requestID == "" || len(seen) != 1 || seen[0] != "DELETE "+requestID {
+		t.Errorf("Expected the middlewares to run in order on the request, got %v and request ID %q", seen, requestID)
+	}
+}
+
+func TestClientRedactsPersonalDataFromLogs(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		res.WriteHeader(201)
+		res.Write(body)
+	}))
+	defer func() { testServer.Close() }()
+
+	var output bytes.Buffer
+	client, _ := NewClient(
+		WithBaseURL(testServer.URL),
+		WithRequestLogger(httpclient.RequestLogger{
+			Logger:    slog.New(slog.NewTextHandler(&output, nil)),
+			LogBodies: true,
+		}),
+	)
+
+	var req CreateRequest
+	req.Data = &Data{Account: &models.Account{Type: "accounts", ID: uuid.New(), Attributes: &models.AccountAttributes{
+		Country:         "GB",
+		AccountNumber:   "41426819",
+		Iban:            "GB16NWBK40030041426819",
+		FirstName:       "Samantha",
+		BankAccountName: "Samantha Holder",
+		Name:            []string{"Sam Holder"},
+		PrivateIdentification: &models.PrivateIdentification{
+			Identification: "13YH458762",
+			BirthDate:      "2017-07-23",
+		},
+	}}}
+	if _, err := client.Create(context.Background(), &req); err != nil {
+		t.Fatalf("Request is returning an error: got %v", err)
+	}
+
+	if !strings.Contains(output.String(), "request_body=") || !strings.Contains(output.String(), "status=201") {
+		t.Fatalf("Expected the request to be logged with its body, got %v", output.String())
+	}
+	for _, personalData := range []string{"41426819", "GB16NWBK40030041426819", "Samantha", "Sam Holder", "13YH458762", "2017-07-23"} {
+		if strings.Contains(output.String(), personalData) {
+			t.Errorf("%v is logged: %v", personalData, output.String())
+		}
+	}
+}
+
+func TestClientUsesConfiguredHTTPClient(t *testing.T) {
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(204)
+	}))
+	defer func() { testServer.Close() }()
+
+	doer := doerFunc(func(req *http.Request) (*http.Response, error) {
+		callCount = callCount + 1
+		return http.DefaultClient.Do(req)
+	})
+
+	client, _ := NewClient(WithBaseURL(testServer.URL), WithHTTPClient(doer))
+
+	var req DeleteRequest
+	req.AccountID = uuid.New()
+	client.Delete(context.Background(), &req)
+
+	if callCount != 1 {
+		t.Errorf("Configured http client not used: got %v calls expected %v", callCount, 1)
+	}
+}
+
+func TestClientUsesConfiguredRetryPolicy(t *testing.T) {
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		callCount = callCount + 1
+		res.WriteHeader(503)
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(
+		WithBaseURL(testServer.URL),
+		WithRetryPolicy(httpclient.RetryPolicy{MaxAttempts: 2}),
+	)
+
+	var req FetchRequest
+	req.AccountID = uuid.New()
+	_, err := client.Fetch(context.Background(), &req)
+	if err == nil {
+		t.Errorf("Request is not returning an error")
+	}
+	if callCount != 2 {
+		t.Errorf("Configured retry policy not used: got %v calls expected %v", callCount, 2)
+	}
+}
+
+func TestClientSharesCircuitBreaker(t *testing.T) {
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		callCount = callCount + 1
+		res.WriteHeader(503)
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(
+		WithBaseURL(testServer.URL),
+		WithRetryPolicy(httpclient.RetryPolicy{MaxAttempts: 1}),
+		WithCircuitBreaker(&httpclient.CircuitBreaker{MinimumRequests: 2}),
+	)
+
+	// every call creates its own httpclient, the breaker must still see all of them
+	for i := 0; i < 3; i++ {
+		var req FetchRequest
+		req.AccountID = uuid.New()
+		_, err := client.Fetch(context.Background(), &req)
+		if i == 2 && !errors.Is(err, httpclient.ErrCircuitOpen) {
+			t.Errorf("Expected the circuit to be open, got %v", err)
+		}
+	}
+	if callCount != 2 {
+		t.Errorf("Requests sent while the circuit is open: got %v calls expected %v", callCount, 2)
+	}
+}
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,268 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"form3-interview/fakeapi"
+	"form3-interview/httpclient"
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+// newFakeAPIClient returns a client calling an in memory account API
+func newFakeAPIClient(t *testing.T) (*Client, *fakeapi.Server) {
+	server := fakeapi.New()
+	testServer := httptest.NewServer(server)
+	t.Cleanup(testServer.Close)
+
+	client, err := NewClient(WithBaseURL(testServer.URL), WithRetryPolicy(httpclient.RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatalf("Unable to create the client: %v", err)
+	}
+	return client, server
+}
+
+func newFakeAPIAccount(country string, bankID string) *models.Account {
+	return &models.Account{
+		Type:           "accounts",
+		ID:             uuid.New(),
+		OrganisationID: uuid.New(),
+		Attributes: &models.AccountAttributes{
+			Country:    country,
+			BankID:     bankID,
+			BankIDCode: "GBDSC",
+			Bic:        "NWBKGB22",
+			// CheckAccountResponse expects at least one alternative name
+			AlternativeBankAccountNames: []string{"Sam Holder"},
+			Name:                        []string{"Samantha Holder"},
+			NameMatchingStatus:          "supported",
+			PrivateIdentification:       &models.PrivateIdentification{Identification: "13YH458762", BirthDate: "2017-07-23", Address: []string{"10 Avenue des Champs"}},
+			UserDefinedData:             []models.UserDefinedData{{Key: "reference", Value: "1"}},
+		},
+	}
+}
+
+func TestFakeAPIAccountLifecycle(t *testing.T) {
+	client, _ := newFakeAPIClient(t)
+	ctx := context.Background()
+
+	newAccount := newFakeAPIAccount("GB", "400300")
+	created, err := client.Create(ctx, &CreateRequest{Data: &Data{Account: newAccount}})
+	if err != nil {
+		t.Fatalf("Create returned an error: %v", err)
+	}
+	CheckAccountResponse(t, created, newAccount)
+
+	_, err = client.Create(ctx, &CreateRequest{Data: &Data{Account: newAccount}})
+	if !httpclient.IsConflict(err) {
+		t.Errorf("Expected a conflict creating the account twice, got %v", err)
+	}
+
+	fetched, err := client.Fetch(ctx, &FetchRequest{AccountID: newAccount.ID})
+	if err != nil {
+		t.Fatalf("Fetch returned an error: %v", err)
+	}
+	CheckAccountResponse(t, fetched, newAccount)
+
+	changes := &models.Account{ID: newAccount.ID, Type: "accounts", Version: 0, Attributes: &models.AccountAttributes{CustomerID: "Ref456"}}
+	updated, err := client.Update(ctx, &UpdateRequest{Data: &Data{Account: changes}})
+	if err != nil {
+		t.Fatalf("Update returned an error: %v", err)
+	}
+	if updated.Version != 1 || updated.Attributes.CustomerID != "Ref456" || updated.Attributes.BankID != "400300" {
+		t.Errorf("Unexpected account after update: %+v", updated.Attributes)
+	}
+
+	_, err = client.Update(ctx, &UpdateRequest{Data: &Data{Account: changes}})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected a version conflict, got %v", err)
+	}
+
+	err = client.Delete(ctx, &DeleteRequest{AccountID: newAccount.ID, Version: 1})
+	if err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+
+	_, err = client.Fetch(ctx, &FetchRequest{AccountID: newAccount.ID})
+	if !httpclient.IsNotFound(err) {
+		t.Errorf("Expected not found after delete, got %v", err)
+	}
+}
+
+func TestFakeAPIListFiltersAndPages(t *testing.T) {
+	client, server := newFakeAPIClient(t)
+	ctx := context.Background()
+
+	for i := 0; i < 25; i++ {
+		country := "GB"
+		if i%5 == 0 {
+			country = "FR"
+		}
+		server.Add(*newFakeAPIAccount(country, fmt.Sprintf("%06d", i)))
+	}
+
+	page, err := client.List(ctx, &ListRequest{PageSize: 10, Country: []string{"FR"}})
+	if err != nil {
+		t.Fatalf("List returned an error: %v", err)
+	}
+	if len(page) != 5 {
+		t.Errorf("Expected 5 accounts in FR, got %v", len(page))
+	}
+
+	for _, concurrency := range []int{1, 3} {
+		accounts, err := client.ListAll(ctx, &ListRequest{PageSize: 4, Country: []string{"GB"}}, WithConcurrency(concurrency))
+		if err != nil {
+			t.Fatalf("ListAll returned an error: %v", err)
+		}
+		if len(accounts) != 20 {
+			t.Errorf("Expected 20 accounts in GB with concurrency %v, got %v", concurrency, len(accounts))
+		}
+		for i := 1; i < len(accounts); i++ {
+			if accounts[i-1].Attributes.BankID >= accounts[i].Attributes.BankID {
+				t.Errorf("Accounts out of order with concurrency %v at %v", concurrency, i)
+			}
+		}
+	}
+}
+
+func TestFakeAPIWithClientCredentials(t *testing.T) {
+	tokens := fakeapi.NewTokenServer("client", "s3cret")
+	mux := http.NewServeMux()
+	mux.Handle(fakeapi.TokenPath, tokens)
+	mux.Handle("/", tokens.Protect(fakeapi.New()))
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	client, _ := NewClient(
+		WithBaseURL(testServer.URL),
+		WithRetryPolicy(httpclient.RetryPolicy{MaxAttempts: 1}),
+		WithClientCredentials(testServer.URL+fakeapi.TokenPath, "client", "s3cret"),
+	)
+
+	newAccount := newFakeAPIAccount("GB", "400300")
+	var createReq CreateRequest
+	createReq.Data = &Data{Account: newAccount}
+	if _, err := client.Create(context.Background(), &createReq); err != nil {
+		t.Fatalf("Create is returning an error: %v", err)
+	}
+
+	tokens.RevokeAll()
+	var fetchReq FetchRequest
+	fetchReq.AccountID = newAccount.ID
+	if _, err := client.Fetch(context.Background(), &fetchReq); err != nil {
+		t.Errorf("Fetch with a revoked token is returning an error: %v", err)
+	}
+	if tokens.Issued() != 2 {
+		t.Errorf("Expected the revoked token to be replaced, got %v tokens issued", tokens.Issued())
+	}
+}
+
+func TestFakeAPIMetricsPerOperation(t *testing.T) {
+	testServer := httptest.NewServer(fakeapi.New())
+	t.Cleanup(testServer.Close)
+
+	metrics := httpclient.NewPrometheusMetrics("account_api")
+	client, _ := NewClient(WithBaseURL(testServer.URL), WithRetryPolicy(httpclient.RetryPolicy{MaxAttempts: 1}), WithMetrics(metrics))
+	ctx := context.Background()
+
+	newAccount := newFakeAPIAccount("GB", "400300")
+	client.Create(ctx, &CreateRequest{Data: &Data{Account: newAccount}})
+	client.Fetch(ctx, &FetchRequest{AccountID: newAccount.ID})
+	client.Fetch(ctx, &FetchRequest{AccountID: uuid.New()})
+	client.List(ctx, &ListRequest{PageSize: 10})
+	client.Delete(ctx, &DeleteRequest{AccountID: newAccount.ID})
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, sample := range []string{
+		`account_api_requests_total{operation="create",method="POST",status="201"} 1`,
+		`account_api_requests_total{operation="fetch",method="GET",status="200"} 1`,
+		`account_api_request_errors_total{operation="fetch",method="GET",status="404"} 1`,
+		`account_api_requests_total{operation="list",method="GET",status="200"} 1`,
+		`account_api_requests_total{operation="delete",method="DELETE",status="204"} 1`,
+		`account_api_request_duration_seconds_count{operation="fetch",method="GET"} 2`,
+	} {
+		if !strings.Contains(recorder.Body.String(), sample+"\n") {
+			t.Errorf("Expected %v in the metrics:\n%v", sample, recorder.Body.String())
+		}
+	}
+}
+
+func TestFakeAPIOperationSpans(t *testing.T) {
+	testServer := httptest.NewServer(fakeapi.New())
+	t.Cleanup(testServer.Close)
+
+	recorder := httpclient.NewSpanRecorder()
+	client, _ := NewClient(WithBaseURL(testServer.URL), WithRetryPolicy(httpclient.RetryPolicy{MaxAttempts: 1}), WithTracer(recorder))
+	ctx := context.Background()
+
+	newAccount := newFakeAPIAccount("GB", "400300")
+	if _, err := client.Create(ctx, &CreateRequest{Data: &Data{Account: newAccount}}); err != nil {
+		t.Fatalf("Create returned an error: %v", err)
+	}
+	missingID := uuid.New()
+	client.Fetch(ctx, &FetchRequest{AccountID: missingID})
+
+	spans := recorder.Spans()
+	if len(spans) != 4 {
+		t.Fatalf("Expected a span per operation and per attempt, got %+v", spans)
+	}
+
+	create, post := spans[0], spans[1]
+	if create.Name != "account.create" || create.Attributes["operation"] != "create" || create.Attributes["account.id"] != newAccount.ID.String() || len(create.Errors) != 0 {
+		t.Errorf("Unexpected span of the creation %+v", create)
+	}
+	if post.Name != "HTTP POST" || post.Parent != create.SpanContext || post.Attributes["http.response.status_code"] != http.StatusCreated {
+		t.Errorf("Expected the attempt to be a child of the operation, got %+v", post)
+	}
+
+	fetch := spans[2]
+	if fetch.Name != "account.fetch" || fetch.Attributes["account.id"] != missingID.String() || !fetch.Ended {
+		t.Errorf("Unexpected span of the fetch %+v", fetch)
+	}
+	if len(fetch.Errors) != 1 || fetch.Attributes["http.response.status_code"] != http.StatusNotFound {
+		t.Errorf("Expected the fetch span to record the 404, got %+v", fetch)
+	}
+}
+
+func TestFakeAPIConcurrentCalls(t *testing.T) {
+	client, server := newFakeAPIClient(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			newAccount := newFakeAPIAccount("GB", fmt.Sprintf("4003%02d", i))
+			if _, err := client.Create(ctx, &CreateRequest{Data: &Data{Account: newAccount}}); err != nil {
+				t.Errorf("Create %v returned an error: %v", i, err)
+				return
+			}
+			fetched, err := client.Fetch(ctx, &FetchRequest{AccountID: newAccount.ID})
+			if err != nil || fetched.ID != newAccount.ID {
+				t.Errorf("Fetch %v returned %v, %v", i, fetched, err)
+			}
+			if _, err := client.List(ctx, &ListRequest{PageNumber: i % 3, PageSize: 5}); err != nil {
+				t.Errorf("List %v returned an error: %v", i, err)
+			}
+			if err := client.Delete(ctx, &DeleteRequest{AccountID: newAccount.ID}); err != nil {
+				t.Errorf("Delete %v returned an error: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(server.Accounts()) != 0 {
+		t.Errorf("Expected every account to be deleted, %v left", len(server.Accounts()))
+	}
+}
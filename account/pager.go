@@ -0,0 +1,232 @@
+// Package account provides methods for creating, retrieving or deleteing accounts.
+package account
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"form3-interview/models"
+)
+
+// PageOption configures how the pages of a list are fetched
+type PageOption func(*pageConfig)
+
+type pageConfig struct {
+	maxItems    int
+	concurrency int
+}
+
+// WithMaxItems stops the iteration once maxItems accounts have been returned
+func WithMaxItems(maxItems int) PageOption {
+	return func(c *pageConfig) {
+		c.maxItems = maxItems
+	}
+}
+
+// WithConcurrency lets ListAll fetch up to concurrency pages at the same time.
+// Pages are fetched concurrently only when the API returns the link to the last page.
+func WithConcurrency(concurrency int) PageOption {
+	return func(c *pageConfig) {
+		c.concurrency = concurrency
+	}
+}
+
+func newPageConfig(opts []PageOption) pageConfig {
+	config := pageConfig{concurrency: 1}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// Pager iterates over the pages of a list of accounts following the next link returned by the API.
+// A Pager is not safe for concurrent use.
+type Pager struct {
+	client  *Client
+	request ListRequest
+	config  pageConfig
+	links   *Links
+	meta    *Meta
+	count   int
+	done    bool
+}
+
+// Pager returns a Pager over the accounts matching the filters of the request.
+// The iteration starts from the page number of the request.
+func (c *Client) Pager(request *ListRequest, opts ...PageOption) *Pager {
+	return &Pager{
+		client:  c,
+		request: *request,
+		config:  newPageConfig(opts),
+	}
+}
+
+// More reports whether there are more pages to fetch
+func (p *Pager) More() bool {
+	return !p.done
+}
+
+// Links returns the links of the last page fetched, nil before the first page
+func (p *Pager) Links() *Links {
+	return p.links
+}
+
+// Meta returns the metadata of the last page fetched, nil when the API did not return any
+func (p *Pager) Meta() *Meta {
+	return p.meta
+}
+
+// Next fetches the next page of accounts.
+// Once the last page has been returned More reports false and Next returns no accounts.
+func (p *Pager) Next(ctx context.Context) ([]models.Account, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	page, err := p.client.ListPage(ctx, &p.request)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := page.Accounts
+	if p.config.maxItems > 0 && p.count+len(accounts) >= p.config.maxItems {
+		accounts = accounts[:p.config.maxItems-p.count]
+		p.done = true
+	}
+	p.count = p.count + len(accounts)
+	p.links = page.Links
+	p.meta = page.Meta
+
+	if len(page.Accounts) == 0 || page.Links == nil || page.Links.Next == "" {
+		p.done = true
+		return accounts, nil
+	}
+
+	nextPage, ok := pageNumberFromLink(page.Links.Next)
+	if !ok {
+		p.done = true
+		return accounts, errors.New("unable to follow the next link: " + page.Links.Next)
+	}
+	p.request.PageNumber = nextPage
+
+	return accounts, nil
+}
+
+// ListAll fetches every account matching the filters of the request, page after page.
+// With WithConcurrency the pages after the first one are fetched in parallel.
+func (c *Client) ListAll(ctx context.Context, request *ListRequest, opts ...PageOption) ([]models.Account, error) {
+	config := newPageConfig(opts)
+	pager := c.Pager(request, opts...)
+
+	accounts, err := pager.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.concurrency > 1 && pager.More() {
+		lastPage, ok := pageNumberFromLink(pager.links.Last)
+		if ok {
+			return c.listConcurrently(ctx, request, accounts, lastPage, config)
+		}
+	}
+
+	// fall back on following the next links
+	for pager.More() {
+		page, err := pager.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, page...)
+	}
+
+	return accounts, nil
+}
+
+// listConcurrently fetches the pages after the first one up to lastPage with bounded parallelism.
+// The accounts are returned in the same order as the pages.
+func (c *Client) listConcurrently(ctx context.Context, request *ListRequest, firstPage []models.Account, lastPage int, config pageConfig) ([]models.Account, error) {
+	firstPageNumber := request.PageNumber
+
+	// do not fetch more pages than needed to reach the max items
+	if config.maxItems > 0 && len(firstPage) > 0 {
+		neededPages := (config.maxItems + len(firstPage) - 1) / len(firstPage)
+		if firstPageNumber+neededPages-1 < lastPage {
+			lastPage = firstPageNumber + neededPages - 1
+		}
+	}
+
+	if lastPage < firstPageNumber {
+		lastPage = firstPageNumber
+	}
+
+	// the first error cancels the pages still in flight
+	pageCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make([][]models.Account, lastPage-firstPageNumber+1)
+	pages[0] = firstPage
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	semaphore := make(chan struct{}, config.concurrency)
+
+	for i := 1; i < len(pages) && pageCtx.Err() == nil; i++ {
+		pageRequest := *request
+		pageRequest.PageNumber = firstPageNumber + i
+		index := i
+
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			page, err := c.ListPage(pageCtx, &pageRequest)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			pages[index] = page.Accounts
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var accounts []models.Account
+	for _, page := range pages {
+		accounts = append(accounts, page...)
+	}
+	if config.maxItems > 0 && len(accounts) > config.maxItems {
+		accounts = accounts[:config.maxItems]
+	}
+
+	return accounts, nil
+}
+
+// pageNumberFromLink reads the page number from a pagination link
+func pageNumberFromLink(link string) (int, bool) {
+	uri, err := url.Parse(link)
+	if err != nil {
+		return 0, false
+	}
+
+	pageNumber, err := strconv.Atoi(uri.Query().Get("page[number]"))
+	if err != nil {
+		return 0, false
+	}
+
+	return pageNumber, true
+}
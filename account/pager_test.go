@@ -0,0 +1,171 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+// newPagedTestServer serves numberOfAccounts accounts split in pages, with the links to navigate them
+func newPagedTestServer(t *testing.T, numberOfAccounts int, withLastLink bool) (*httptest.Server, []models.Account, *int) {
+	var accounts []models.Account
+	for i := 0; i < numberOfAccounts; i++ {
+		accounts = append(accounts, models.Account{ID: uuid.New(), Type: "accounts"})
+	}
+
+	var mutex sync.Mutex
+	callCount := 0
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		callCount = callCount + 1
+		mutex.Unlock()
+
+		pageNumber, _ := strconv.Atoi(req.URL.Query().Get("page[number]"))
+		pageSize, _ := strconv.Atoi(req.URL.Query().Get("page[size]"))
+		if pageSize == 0 {
+			pageSize = defaultPageSize
+		}
+		lastPage := (numberOfAccounts - 1) / pageSize
+
+		start := pageNumber * pageSize
+		end := start + pageSize
+		if start > numberOfAccounts {
+			start = numberOfAccounts
+		}
+		if end > numberOfAccounts {
+			end = numberOfAccounts
+		}
+
+		link := func(page int) string {
+			return fmt.Sprintf("/v1/organisation/accounts?page%%5Bnumber%%5D=%d&page%%5Bsize%%5D=%d", page, pageSize)
+		}
+
+		list := AccountList{Accounts: accounts[start:end], Links: &Links{Self: link(pageNumber), First: link(0)}}
+		if withLastLink {
+			list.Links.Last = link(lastPage)
+		}
+		if pageNumber < lastPage {
+			list.Links.Next = link(pageNumber + 1)
+		}
+
+		body, _ := json.Marshal(list)
+		res.WriteHeader(200)
+		res.Write(body)
+	}))
+
+	return testServer, accounts, &callCount
+}
+
+func checkAccountIDs(t *testing.T, resp []models.Account, expected []models.Account) {
+	if len(resp) != len(expected) {
+		t.Fatalf("Number of accounts returned is wrong: got %v expected %v", len(resp), len(expected))
+	}
+	for i := range resp {
+		if resp[i].ID != expected[i].ID {
+			t.Errorf("Account %v is wrong: got %v expected %v", i, resp[i].ID, expected[i].ID)
+		}
+	}
+}
+
+func TestPagerFollowsNextLinks(t *testing.T) {
+	testServer, accounts, callCount := newPagedTestServer(t, 25, false)
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+	pager := client.Pager(&ListRequest{PageSize: 10})
+
+	var resp []models.Account
+	for pager.More() {
+		page, err := pager.Next(context.Background())
+		if err != nil {
+			t.Fatalf("Request is returning an error: got %v", err.Error())
+		}
+		resp = append(resp, page...)
+	}
+
+	checkAccountIDs(t, resp, accounts)
+	if *callCount != 3 {
+		t.Errorf("Wrong number of pages fetched: got %v expected %v", *callCount, 3)
+	}
+	if pager.Links() == nil || pager.Links().Next != "" || pager.Links().Self == "" {
+		t.Errorf("Expected the links of the last page, got %+v", pager.Links())
+	}
+}
+
+func TestPagerMaxItems(t *testing.T) {
+	testServer, accounts, callCount := newPagedTestServer(t, 25, false)
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+	resp, err := client.ListAll(context.Background(), &ListRequest{PageSize: 10}, WithMaxItems(15))
+	if err != nil {
+		t.Fatalf("Request is returning an error: got %v", err.Error())
+	}
+
+	checkAccountIDs(t, resp, accounts[:15])
+	if *callCount != 2 {
+		t.Errorf("Wrong number of pages fetched: got %v expected %v", *callCount, 2)
+	}
+}
+
+func TestListAllConcurrently(t *testing.T) {
+	testServer, accounts, callCount := newPagedTestServer(t, 95, true)
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+	resp, err := client.ListAll(context.Background(), &ListRequest{PageSize: 10}, WithConcurrency(4))
+	if err != nil {
+		t.Fatalf("Request is returning an error: got %v", err.Error())
+	}
+
+	checkAccountIDs(t, resp, accounts)
+	if *callCount != 10 {
+		t.Errorf("Wrong number of pages fetched: got %v expected %v", *callCount, 10)
+	}
+}
+
+func TestListAllConcurrentlyMaxItems(t *testing.T) {
+	testServer, accounts, callCount := newPagedTestServer(t, 95, true)
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+	resp, err := client.ListAll(context.Background(), &ListRequest{PageSize: 10}, WithConcurrency(4), WithMaxItems(35))
+	if err != nil {
+		t.Fatalf("Request is returning an error: got %v", err.Error())
+	}
+
+	checkAccountIDs(t, resp, accounts[:35])
+	if *callCount != 4 {
+		t.Errorf("Wrong number of pages fetched: got %v expected %v", *callCount, 4)
+	}
+}
+
+func TestListAllReturnsPageError(t *testing.T) {
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		callCount = callCount + 1
+		if callCount > 1 {
+			res.WriteHeader(404)
+			return
+		}
+		res.WriteHeader(200)
+		res.Write([]byte(`{"data":[{"type":"accounts"}],"links":{"next":"/v1/organisation/accounts?page%5Bnumber%5D=1"}}`))
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := NewClient(WithBaseURL(testServer.URL))
+	_, err := client.ListAll(context.Background(), &ListRequest{})
+	if err == nil || err.Error() != "404 Not Found" {
+		t.Errorf("Request is returning an unexpected error: got %v", err)
+	}
+}
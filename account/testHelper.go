@@ -5,6 +5,7 @@ import (
 	"form3-interview/models"
 	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -38,6 +39,9 @@ func CheckAccountResponse(t *testing.T, resp *models.Account, expectedAccount *m
 	if resp.Attributes.Bic != expectedAccount.Attributes.Bic {
 		t.Errorf("Response contains wrong Bic, got %v expected %v", resp.Attributes.Bic, expectedAccount.Attributes.Bic)
 	}
+	if resp.Attributes.Iban != expectedAccount.Attributes.Iban {
+		t.Errorf("Response contains wrong Iban, got %v expected %v", resp.Attributes.Iban, expectedAccount.Attributes.Iban)
+	}
 	if resp.Attributes.AccountNumber != expectedAccount.Attributes.AccountNumber {
 		t.Errorf("Response contains wrong AccountNumber, got %v expected %v", resp.Attributes.AccountNumber, expectedAccount.Attributes.AccountNumber)
 	}
@@ -87,6 +91,39 @@ func CheckAccountResponse(t *testing.T, resp *models.Account, expectedAccount *m
 	if resp.Attributes.SecondaryIdentification != expectedAccount.Attributes.SecondaryIdentification {
 		t.Errorf("Response contains wrong SecondaryIdentification, got %v expected %v", resp.Attributes.SecondaryIdentification, expectedAccount.Attributes.SecondaryIdentification)
 	}
+	if !reflect.DeepEqual(resp.Attributes.Name, expectedAccount.Attributes.Name) {
+		t.Errorf("Response contains wrong Name, got %v expected %v", resp.Attributes.Name, expectedAccount.Attributes.Name)
+	}
+	if !reflect.DeepEqual(resp.Attributes.AlternativeNames, expectedAccount.Attributes.AlternativeNames) {
+		t.Errorf("Response contains wrong AlternativeNames, got %v expected %v", resp.Attributes.AlternativeNames, expectedAccount.Attributes.AlternativeNames)
+	}
+	if resp.Attributes.NameMatchingStatus != expectedAccount.Attributes.NameMatchingStatus {
+		t.Errorf("Response contains wrong NameMatchingStatus, got %v expected %v", resp.Attributes.NameMatchingStatus, expectedAccount.Attributes.NameMatchingStatus)
+	}
+	if resp.Attributes.ProcessingService != expectedAccount.Attributes.ProcessingService {
+		t.Errorf("Response contains wrong ProcessingService, got %v expected %v", resp.Attributes.ProcessingService, expectedAccount.Attributes.ProcessingService)
+	}
+	if resp.Attributes.UserDefinedInformation != expectedAccount.Attributes.UserDefinedInformation {
+		t.Errorf("Response contains wrong UserDefinedInformation, got %v expected %v", resp.Attributes.UserDefinedInformation, expectedAccount.Attributes.UserDefinedInformation)
+	}
+	if resp.Attributes.ValidationType != expectedAccount.Attributes.ValidationType {
+		t.Errorf("Response contains wrong ValidationType, got %v expected %v", resp.Attributes.ValidationType, expectedAccount.Attributes.ValidationType)
+	}
+	if resp.Attributes.ReferenceMask != expectedAccount.Attributes.ReferenceMask {
+		t.Errorf("Response contains wrong ReferenceMask, got %v expected %v", resp.Attributes.ReferenceMask, expectedAccount.Attributes.ReferenceMask)
+	}
+	if resp.Attributes.AcceptanceQualifier != expectedAccount.Attributes.AcceptanceQualifier {
+		t.Errorf("Response contains wrong AcceptanceQualifier, got %v expected %v", resp.Attributes.AcceptanceQualifier, expectedAccount.Attributes.AcceptanceQualifier)
+	}
+	if !reflect.DeepEqual(resp.Attributes.PrivateIdentification, expectedAccount.Attributes.PrivateIdentification) {
+		t.Errorf("Response contains wrong PrivateIdentification, got %+v expected %+v", resp.Attributes.PrivateIdentification, expectedAccount.Attributes.PrivateIdentification)
+	}
+	if !reflect.DeepEqual(resp.Attributes.OrganisationIdentification, expectedAccount.Attributes.OrganisationIdentification) {
+		t.Errorf("Response contains wrong OrganisationIdentification, got %+v expected %+v", resp.Attributes.OrganisationIdentification, expectedAccount.Attributes.OrganisationIdentification)
+	}
+	if !reflect.DeepEqual(resp.Attributes.UserDefinedData, expectedAccount.Attributes.UserDefinedData) {
+		t.Errorf("Response contains wrong UserDefinedData, got %v expected %v", resp.Attributes.UserDefinedData, expectedAccount.Attributes.UserDefinedData)
+	}
 }
 
 func readMockedResponseFromFile(t *testing.T, fileName string) string {
@@ -0,0 +1,613 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"strings"
+
+	"form3-interview/account"
+	"form3-interview/httpclient"
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+const accountsUsage = `Usage:
+  main accounts create [--file account.json] [--skip-validation] [--generate-iban] [attribute flags]
+  main accounts get --id <account id>
+  main accounts list [--page-number n] [--page-size n] [--all] [filter flags]
+  main accounts update --id <account id> --version <version> [--file account.json] [attribute flags]
+  main accounts delete --id <account id> --version <version>
+`
+
+// runAccounts executes one of the accounts subcommands
+func runAccounts(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, accountsUsage)
+		return exitUsage
+	}
+
+	var command func(args []string, stdout io.Writer, stderr io.Writer) int
+	switch args[0] {
+	case "create":
+		command = runCreate
+	case "get":
+		command = runGet
+	case "list":
+		command = runList
+	case "update":
+		command = runUpdate
+	case "delete":
+		command = runDelete
+	default:
+		fmt.Fprintf(stderr, "Unknown accounts command %q\n\n%s", args[0], accountsUsage)
+		return exitUsage
+	}
+
+	return command(args[1:], stdout, stderr)
+}
+
+func runCreate(args []string, stdout io.Writer, stderr io.Writer) int {
+	var options commandOptions
+	var newAccount models.Account
+	flags := accountFlags("create", &newAccount, &options, stderr)
+	addOutputFlags(flags.FlagSet, &options.output)
+	skipValidation := flags.Bool("skip-validation", false, "send the account without validating it first")
+	generateIban := flags.Bool("generate-iban", false, "derive the IBAN from the country, bank ID, BIC and account number when it is not set")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := options.output.validate(); err != nil {
+		return usageError(stderr, flags.FlagSet, err.Error())
+	}
+
+	// flags set on the command line override the content of the file
+	if *flags.file != "" {
+		fileAccount, err := readAccountFile(*flags.file)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if err := flags.overlay(fileAccount, &newAccount); err != nil {
+			return fail(stderr, err)
+		}
+	}
+
+	if newAccount.ID == uuid.Nil {
+		newAccount.ID = uuid.New()
+	}
+	if newAccount.OrganisationID == uuid.Nil {
+		newAccount.OrganisationID = uuid.New()
+	}
+	if newAccount.Type == "" {
+		newAccount.Type = "accounts"
+	}
+
+	if *generateIban {
+		if err := newAccount.Attributes.FillIban(); err != nil {
+			return fail(stderr, fmt.Errorf("unable to generate the IBAN: %w", err))
+		}
+	}
+
+	if !*skipValidation {
+		if err := newAccount.Validate(); err != nil {
+			printValidationErrors(stderr, err)
+			return exitUsage
+		}
+	}
+
+	client, err := newClient(&options)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	ctx, cancel := options.context()
+	defer cancel()
+
+	var req account.CreateRequest
+	req.Data = &account.Data{Account: &newAccount}
+
+	resp, err := client.Create(ctx, &req)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	return printResult(stdout, stderr, &options.output, []models.Account{*resp}, true)
+}
+
+func runGet(args []string, stdout io.Writer, stderr io.Writer) int {
+	var options commandOptions
+	flags := newFlagSet("get", &options, stderr)
+	accountID := flags.String("id", "", "ID of the account to fetch")
+	addOutputFlags(flags, &options.output)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := options.output.validate(); err != nil {
+		return usageError(stderr, flags, err.Error())
+	}
+
+	var req account.FetchRequest
+	var err error
+	req.AccountID, err = uuid.Parse(*accountID)
+	if err != nil {
+		return usageError(stderr, flags, "--id must be a valid UUID")
+	}
+
+	client, err := newClient(&options)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	ctx, cancel := options.context()
+	defer cancel()
+
+	resp, err := client.Fetch(ctx, &req)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	return printResult(stdout, stderr, &options.output, []models.Account{*resp}, true)
+}
+
+func runList(args []string, stdout io.Writer, stderr io.Writer) int {
+	var options commandOptions
+	var req account.ListRequest
+	flags := newFlagSet("list", &options, stderr)
+	flags.IntVar(&req.PageNumber, "page-number", 0, "page number to fetch")
+	flags.IntVar(&req.PageSize, "page-size", 0, "number of accounts in a page")
+	flags.Var((*stringList)(&req.BankID), "bank-id", "comma separated list of bank IDs to filter on")
+	flags.Var((*stringList)(&req.AccountNumber), "account-number", "comma separated list of account numbers to filter on")
+	flags.Var((*stringList)(&req.Iban), "iban", "comma separated list of IBANs to filter on")
+	flags.Var((*stringList)(&req.CustomerID), "customer-id", "comma separated list of customer IDs to filter on")
+	flags.Var((*stringList)(&req.Country), "country", "comma separated list of countries to filter on")
+	all := flags.Bool("all", false, "follow the next links and fetch every page")
+	addOutputFlags(flags, &options.output)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := options.output.validate(); err != nil {
+		return usageError(stderr, flags, err.Error())
+	}
+
+	client, err := newClient(&options)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	ctx, cancel := options.context()
+	defer cancel()
+
+	var resp []models.Account
+	if *all {
+		resp, err = client.ListAll(ctx, &req)
+	} else {
+		resp, err = client.List(ctx, &req)
+	}
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	return printResult(stdout, stderr, &options.output, resp, false)
+}
+
+func runUpdate(args []string, stdout io.Writer, stderr io.Writer) int {
+	var options commandOptions
+	var changes models.Account
+	flags := accountFlags("update", &changes, &options, stderr)
+	addOutputFlags(flags.FlagSet, &options.output)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := options.output.validate(); err != nil {
+		return usageError(stderr, flags.FlagSet, err.Error())
+	}
+
+	// flags set on the command line override the content of the file
+	if *flags.file != "" {
+		fileAccount, err := readAccountFile(*flags.file)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if err := flags.overlay(fileAccount, &changes); err != nil {
+			return fail(stderr, err)
+		}
+	}
+
+	if changes.ID == uuid.Nil {
+		return usageError(stderr, flags.FlagSet, "--id is mandatory to update an account")
+	}
+	// the version guards the update against concurrent changes, it has no safe default
+	if !isSet(flags.FlagSet, "version") {
+		return usageError(stderr, flags.FlagSet, "--version is mandatory to update an account")
+	}
+	if changes.Type == "" {
+		changes.Type = "accounts"
+	}
+
+	client, err := newClient(&options)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	ctx, cancel := options.context()
+	defer cancel()
+
+	var req account.UpdateRequest
+	req.Data = &account.Data{Account: &changes}
+	req.Flags = booleanChanges(flags.FlagSet, changes.Attributes)
+
+	resp, err := client.Update(ctx, &req)
+	if errors.Is(err, account.ErrVersionConflict) {
+		return fail(stderr, errors.New("the account has been modified, fetch it again to get the current version"))
+	}
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	return printResult(stdout, stderr, &options.output, []models.Account{*resp}, true)
+}
+
+func runDelete(args []string, stdout io.Writer, stderr io.Writer) int {
+	var options commandOptions
+	var req account.DeleteRequest
+	flags := newFlagSet("delete", &options, stderr)
+	accountID := flags.String("id", "", "ID of the account to delete")
+	flags.IntVar(&req.Version, "version", 0, "current version of the account")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	var err error
+	req.AccountID, err = uuid.Parse(*accountID)
+	if err != nil {
+		return usageError(stderr, flags, "--id must be a valid UUID")
+	}
+	// like an update, a delete is guarded by the current version
+	if !isSet(flags, "version") {
+		return usageError(stderr, flags, "--version is mandatory to delete an account")
+	}
+
+	client, err := newClient(&options)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	ctx, cancel := options.context()
+	defer cancel()
+
+	err = client.Delete(ctx, &req)
+	if err != nil {
+		return fail(stderr, err)
+	}
+
+	fmt.Fprintln(stdout, "Account deleted succesfuly")
+	return exitOK
+}
+
+// accountFlagSet is the flag set of a command sending an account
+type accountFlagSet struct {
+	*flag.FlagSet
+
+	// file is the path of the JSON file to read the account from
+	file *string
+
+	// fields are the flags bound to the account, named after its JSON fields
+	fields map[string]bool
+}
+
+// accountFlags creates the flags of a command sending an account, bound to the account passed through
+func accountFlags(name string, newAccount *models.Account, options *commandOptions, stderr io.Writer) *accountFlagSet {
+	if newAccount.Attributes == nil {
+		newAccount.Attributes = &models.AccountAttributes{}
+	}
+	attrs := newAccount.Attributes
+
+	flags := newFlagSet(name, options, stderr)
+	file := flags.String("file", "", "JSON file containing the account, - to read it from stdin")
+	common := make(map[string]bool)
+	flags.VisitAll(func(f *flag.Flag) {
+		common[f.Name] = true
+	})
+	flags.Var((*uuidValue)(&newAccount.ID), "id", "ID of the account")
+	flags.Var((*uuidValue)(&newAccount.OrganisationID), "organisation-id", "ID of the organisation owning the account")
+	flags.IntVar(&newAccount.Version, "version", newAccount.Version, "current version of the account")
+	flags.StringVar(&attrs.Country, "country", attrs.Country, "ISO 3166-1 country code, e.g. GB")
+	flags.StringVar(&attrs.BaseCurrency, "base-currency", attrs.BaseCurrency, "ISO 4217 currency code, e.g. GBP")
+	flags.StringVar(&attrs.BankID, "bank-id", attrs.BankID, "local country bank identifier")
+	flags.StringVar(&attrs.BankIDCode, "bank-id-code", attrs.BankIDCode, "type of bank identifier, e.g. GBDSC")
+	flags.StringVar(&attrs.Bic, "bic", attrs.Bic, "SWIFT BIC in 8 or 11 character format")
+	flags.StringVar(&attrs.AccountNumber, "account-number", attrs.AccountNumber, "account number")
+	flags.StringVar(&attrs.Iban, "iban", attrs.Iban, "IBAN of the account")
+	flags.StringVar(&attrs.CustomerID, "customer-id", attrs.CustomerID, "reference to an external system")
+	flags.Var((*stringList)(&attrs.Name), "name", "comma separated lines of the account holder's name, up to 4")
+	flags.Var((*stringList)(&attrs.AlternativeNames), "alternative-names", "comma separated list of alternative account names, up to 3")
+	flags.StringVar(&attrs.FirstName, "first-name", attrs.FirstName, "account holder's first name, deprecated: use --name")
+	flags.StringVar(&attrs.BankAccountName, "bank-account-name", attrs.BankAccountName, "primary account name, deprecated: use --name")
+	flags.Var((*stringList)(&attrs.AlternativeBankAccountNames), "alternative-bank-account-names", "comma separated list of alternative account names, deprecated: use --alternative-names")
+	flags.StringVar(&attrs.AccountClassification, "account-classification", attrs.AccountClassification, "Personal or Business")
+	flags.BoolVar(&attrs.JointAccount, "joint-account", attrs.JointAccount, "the account is a joint account")
+	flags.BoolVar(&attrs.Switched, "switched", attrs.Switched, "the account has been switched away")
+	flags.BoolVar(&attrs.AccountMatchingOptOut, "account-matching-opt-out", attrs.AccountMatchingOptOut, "the account opted out of account matching")
+	flags.StringVar(&attrs.SecondaryIdentification, "secondary-identification", attrs.SecondaryIdentification, "additional information to identify the account")
+	flags.StringVar(&attrs.NameMatchingStatus, "name-matching-status", attrs.NameMatchingStatus, "supported, switched, opted_out or not_supported")
+	flags.StringVar(&attrs.ProcessingService, "processing-service", attrs.ProcessingService, "name of the service processing the payments")
+	flags.StringVar(&attrs.UserDefinedInformation, "user-defined-information", attrs.UserDefinedInformation, "free-format information about the account")
+	flags.StringVar(&attrs.ValidationType, "validation-type", attrs.ValidationType, "type of validation of the account, e.g. card")
+	flags.StringVar(&attrs.ReferenceMask, "reference-mask", attrs.ReferenceMask, "mask the payment references must match")
+	flags.StringVar(&attrs.AcceptanceQualifier, "acceptance-qualifier", attrs.AcceptanceQualifier, "payments accepted by the account, e.g. same_day")
+	flags.Var(jsonValue{&attrs.PrivateIdentification}, "private-identification", `identification of the account holder as JSON, e.g. '{"identification":"13YH458762","birth_date":"2017-07-23"}'`)
+	flags.Var(jsonValue{&attrs.OrganisationIdentification}, "organisation-identification", `identification of the organisation as JSON, e.g. '{"identification":"123654","actors":[{"name":["Jeff Page"]}]}'`)
+	flags.Var((*userDefinedData)(&attrs.UserDefinedData), "user-defined-data", "comma separated list of key=value pairs")
+
+	fields := make(map[string]bool)
+	flags.VisitAll(func(f *flag.Flag) {
+		fields[f.Name] = !common[f.Name]
+	})
+	return &accountFlagSet{FlagSet: flags, file: file, fields: fields}
+}
+
+// overlay sets the account to the one of the file with the fields set on the command line, which are in the account.
+// The two accounts are merged through their JSON representation.
+func (f *accountFlagSet) overlay(fileAccount *models.Account, flagged *models.Account) error {
+	var merged, set map[string]interface{}
+	content, err := json.Marshal(fileAccount)
+	if err != nil {
+		return err
+	}
+	json.Unmarshal(content, &merged)
+	content, err = json.Marshal(flagged)
+	if err != nil {
+		return err
+	}
+	json.Unmarshal(content, &set)
+
+	mergedAttributes, _ := merged["attributes"].(map[string]interface{})
+	if mergedAttributes == nil {
+		mergedAttributes = make(map[string]interface{})
+	}
+	setAttributes, _ := set["attributes"].(map[string]interface{})
+
+	f.Visit(func(setFlag *flag.Flag) {
+		if !f.fields[setFlag.Name] {
+			return
+		}
+		field := strings.ReplaceAll(setFlag.Name, "-", "_")
+		target, source := mergedAttributes, setAttributes
+		if _, ok := set[field]; ok {
+			target, source = merged, set
+		}
+		// the empty values are left out of the JSON, a field set to one is removed
+		if value, ok := source[field]; ok {
+			target[field] = value
+		} else {
+			delete(target, field)
+		}
+	})
+	merged["attributes"] = mergedAttributes
+
+	content, err = json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	*flagged = models.Account{}
+	return json.Unmarshal(content, flagged)
+}
+
+// isSet tells if the flag has been set on the command line
+func isSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// booleanChanges returns the boolean attributes set on the command line, false included
+func booleanChanges(flags *flag.FlagSet, attrs *models.AccountAttributes) *account.UpdateFlags {
+	var changes account.UpdateFlags
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "joint-account":
+			changes.JointAccount = &attrs.JointAccount
+		case "switched":
+			changes.Switched = &attrs.Switched
+		case "account-matching-opt-out":
+			changes.AccountMatchingOptOut = &attrs.AccountMatchingOptOut
+		}
+	})
+	return &changes
+}
+
+// readAccountFile reads an account from a JSON file, either wrapped in a data object or not
+func readAccountFile(fileName string) (*models.Account, error) {
+	var content []byte
+	var err error
+	if fileName == "-" {
+		content, err = ioutil.ReadAll(stdin)
+	} else {
+		content, err = ioutil.ReadFile(fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data account.Data
+	err = json.Unmarshal(content, &data)
+	if err == nil && data.Account != nil {
+		return data.Account, nil
+	}
+
+	var fileAccount models.Account
+	err = json.Unmarshal(content, &fileAccount)
+	if err != nil {
+		return nil, fmt.Errorf("reading %v: %w", fileName, err)
+	}
+
+	return &fileAccount, nil
+}
+
+// newClient creates the account client from the command options
+func newClient(options *commandOptions) (*account.Client, error) {
+	options.credentialsFromEnvironment()
+	if options.serverURL == "" {
+		return nil, errors.New("the server url is missing, use --server-url or set SERVER_URL")
+	}
+
+	opts := []account.Option{
+		account.WithBaseURL(options.serverURL),
+		account.WithHost(options.host),
+	}
+
+	signing := options.keyID != "" || options.keyFile != ""
+	oauth := options.oauth.tokenURL != "" || options.oauth.clientID != ""
+	if signing && oauth {
+		return nil, errors.New("the requests can be either signed or authenticated with OAuth2, not both")
+	}
+
+	// the staging environments need a bearer token
+	if oauth {
+		if options.oauth.tokenURL == "" || options.oauth.clientID == "" {
+			return nil, errors.New("both --token-url and --client-id are needed to authenticate with OAuth2")
+		}
+		opts = append(opts, account.WithClientCredentials(options.oauth.tokenURL, options.oauth.clientID, options.oauth.clientSecret))
+	}
+
+	// the production API needs signed requests
+	if signing {
+		if options.keyID == "" || options.keyFile == "" {
+			return nil, errors.New("both --key-id and --private-key are needed to sign the requests")
+		}
+		privateKey, err := httpclient.LoadPrivateKeyFile(options.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, account.WithSigner(httpclient.NewHTTPSigner(options.keyID, privateKey)))
+	}
+
+	if options.logging.verbose || options.logging.bodies {
+		opts = append(opts, account.WithRequestLogger(httpclient.RequestLogger{
+			Logger:    slog.New(slog.NewTextHandler(options.logging.output, nil)),
+			LogBodies: options.logging.bodies,
+		}))
+	}
+
+	return account.NewClient(opts...)
+}
+
+func printResult(stdout io.Writer, stderr io.Writer, output *outputOptions, accounts []models.Account, single bool) int {
+	err := printAccounts(stdout, output, accounts, single)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	return exitOK
+}
+
+// printValidationErrors writes every invalid field of an account on its own line
+func printValidationErrors(w io.Writer, err error) {
+	var validationErrors models.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		fmt.Fprintln(w, "Error: ", err)
+		return
+	}
+
+	fmt.Fprintln(w, "Error:  the account is not valid")
+	for _, validationError := range validationErrors {
+		fmt.Fprintf(w, "  %v\n", validationError)
+	}
+}
+
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintln(stderr, "Error: ", err)
+	return exitFailure
+}
+
+func usageError(stderr io.Writer, flags *flag.FlagSet, message string) int {
+	fmt.Fprintln(stderr, "Error: ", message)
+	flags.Usage()
+	return exitUsage
+}
+
+// stringList is a flag holding a comma separated list of values
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = strings.Split(value, ",")
+	return nil
+}
+
+// userDefinedData is a flag holding a comma separated list of key=value pairs
+type userDefinedData []models.UserDefinedData
+
+func (d *userDefinedData) String() string {
+	if d == nil {
+		return ""
+	}
+	pairs := make([]string, len(*d))
+	for i, data := range *d {
+		pairs[i] = data.Key + "=" + data.Value
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (d *userDefinedData) Set(value string) error {
+	*d = nil
+	for _, pair := range strings.Split(value, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return errors.New("expected key=value but got " + pair)
+		}
+		*d = append(*d, models.UserDefinedData{Key: key, Value: value})
+	}
+	return nil
+}
+
+// jsonValue is a flag holding a JSON value decoded into the value it points to
+type jsonValue struct {
+	value interface{}
+}
+
+func (j jsonValue) String() string {
+	if j.value == nil {
+		return ""
+	}
+	content, _ := json.Marshal(j.value)
+	if string(content) == "null" {
+		return ""
+	}
+	return string(content)
+}
+
+func (j jsonValue) Set(value string) error {
+	return json.Unmarshal([]byte(value), j.value)
+}
+
+// uuidValue is a flag holding a UUID
+type uuidValue uuid.UUID
+
+func (u *uuidValue) String() string {
+	if u == nil || uuid.UUID(*u) == uuid.Nil {
+		return ""
+	}
+	return uuid.UUID(*u).String()
+}
+
+func (u *uuidValue) Set(value string) error {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return err
+	}
+	*u = uuidValue(id)
+	return nil
+}
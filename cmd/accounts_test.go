@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+// runAccountsWith runs an accounts subcommand and returns its exit code, stdout and stderr
+func runAccountsWith(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"accounts"}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// decodeAccount reads the account printed as JSON by a command
+func decodeAccount(t *testing.T, stdout string) models.Account {
+	var printed models.Account
+	if err := json.Unmarshal([]byte(stdout), &printed); err != nil {
+		t.Fatalf("The command did not print an account: %v", stdout)
+	}
+	return printed
+}
+
+func TestAccountsCreate(t *testing.T) {
+	server, url := newFakeAPI(t)
+
+	code, stdout, stderr := runAccountsWith("create", "--server-url", url, "--country", "GB", "--base-currency", "GBP",
+		"--bank-id", "400300", "--bank-id-code", "GBDSC", "--bic", "NWBKGB22", "--name", "Jane,Doe")
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+
+	printed := decodeAccount(t, stdout)
+	stored := server.Accounts()
+	if len(stored) != 1 || stored[0].ID != printed.ID {
+		t.Fatalf("Expected the printed account to be stored, got %v", stored)
+	}
+	if stored[0].Attributes.BankID != "400300" || strings.Join(stored[0].Attributes.Name, ",") != "Jane,Doe" {
+		t.Errorf("The account has not been created from the flags: got %+v", stored[0].Attributes)
+	}
+}
+
+func TestAccountsCreateFlagsOverrideTheFile(t *testing.T) {
+	server, url := newFakeAPI(t)
+
+	fileAccount := newTestAccount()
+	fileAccount.Attributes.Name = []string{"Jane Doe"}
+	content, _ := json.Marshal(map[string]interface{}{"data": fileAccount})
+	file := filepath.Join(t.TempDir(), "account.json")
+	if err := ioutil.WriteFile(file, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, stderr := runAccountsWith("create", "--server-url", url, "--file", file, "--bank-id", "400301")
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+
+	stored := server.Accounts()
+	if len(stored) != 1 || stored[0].ID != fileAccount.ID {
+		t.Fatalf("Expected the account of the file to be stored, got %v", stored)
+	}
+	if stored[0].Attributes.BankID != "400301" {
+		t.Errorf("The flag has not overridden the file: got bank id %v", stored[0].Attributes.BankID)
+	}
+	if strings.Join(stored[0].Attributes.Name, ",") != "Jane Doe" || stored[0].Attributes.Bic != "NWBKGB22" {
+		t.Errorf("The attributes of the file have been lost: got %+v", stored[0].Attributes)
+	}
+}
+
+func TestAccountsCreateFromStdin(t *testing.T) {
+	server, url := newFakeAPI(t)
+
+	fileAccount := newTestAccount()
+	fileAccount.Attributes.JointAccount = true
+	fileAccount.Attributes.CustomerID = "Ref123"
+	content, _ := json.Marshal(fileAccount)
+	previous := stdin
+	stdin = bytes.NewReader(content)
+	t.Cleanup(func() { stdin = previous })
+
+	// the flags override the file wherever they are, and clear the values set to empty
+	code, _, stderr := runAccountsWith("create", "--server-url", url, "--bank-id", "400301", "--file", "-", "--joint-account=false", "--customer-id", "")
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+
+	stored := server.Accounts()
+	if len(stored) != 1 || stored[0].ID != fileAccount.ID {
+		t.Fatalf("Expected the account read from stdin to be stored, got %v", stored)
+	}
+	attributes := stored[0].Attributes
+	if attributes.BankID != "400301" || attributes.JointAccount || attributes.CustomerID != "" || attributes.Bic != "NWBKGB22" {
+		t.Errorf("Expected the flags to override the account read from stdin, got %+v", attributes)
+	}
+}
+
+func TestAccountsCreateInvalid(t *testing.T) {
+	server, url := newFakeAPI(t)
+
+	code, stdout, stderr := runAccountsWith("create", "--server-url", url, "--bank-id", "400300")
+	if code != exitUsage || stdout != "" {
+		t.Errorf("Expected exit code %v without output but got %v: %v", exitUsage, code, stdout)
+	}
+	if !strings.Contains(stderr, "the account is not valid") || !strings.Contains(stderr, "country") {
+		t.Errorf("Expected the invalid country to be reported, got %v", stderr)
+	}
+	if len(server.Accounts()) != 0 {
+		t.Errorf("An invalid account has been sent")
+	}
+
+	code, _, stderr = runAccountsWith("create", "--server-url", url, "--file", filepath.Join(t.TempDir(), "missing.json"))
+	if code != exitFailure || !strings.Contains(stderr, "missing.json") {
+		t.Errorf("Expected the missing file to be reported, got %v: %v", code, stderr)
+	}
+}
+
+func TestAccountsGet(t *testing.T) {
+	server, url := newFakeAPI(t)
+	account := newTestAccount()
+	server.Add(account)
+
+	code, stdout, stderr := runAccountsWith("get", "--server-url", url, "--id", account.ID.String())
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+	if printed := decodeAccount(t, stdout); printed.ID != account.ID {
+		t.Errorf("Expected account %v but got %v", account.ID, printed.ID)
+	}
+
+	code, stdout, stderr = runAccountsWith("get", "--server-url", url, "--id", uuid.New().String())
+	if code != exitFailure || stdout != "" || !strings.Contains(stderr, "does not exist") {
+		t.Errorf("Expected the unknown account to be reported, got %v: %v", code, stderr)
+	}
+}
+
+func TestAccountsList(t *testing.T) {
+	server, url := newFakeAPI(t)
+	first, second := newTestAccount(), newTestAccount()
+	second.Attributes.Country = "FR"
+	server.Add(first, second)
+
+	code, stdout, stderr := runAccountsWith("list", "--server-url", url, "--country", "FR", "--output", "csv", "--fields", "id,attributes.country")
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+	expected := "id,attributes.country\n" + second.ID.String() + ",FR\n"
+	if stdout != expected {
+		t.Errorf("Expected %q but got %q", expected, stdout)
+	}
+
+	code, _, stderr = runAccountsWith("list", "--server-url", url, "--output", "xml")
+	if code != exitUsage || !strings.Contains(stderr, `unknown output format "xml"`) {
+		t.Errorf("Expected the unknown format to be reported, got %v: %v", code, stderr)
+	}
+}
+
+func TestAccountsUpdate(t *testing.T) {
+	server, url := newFakeAPI(t)
+	account := newTestAccount()
+	server.Add(account)
+
+	code, stdout, stderr := runAccountsWith("update", "--server-url", url, "--id", account.ID.String(), "--version", "0", "--bank-id", "400301")
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+	printed := decodeAccount(t, stdout)
+	if printed.Version != 1 || printed.Attributes.BankID != "400301" || printed.Attributes.Bic != "NWBKGB22" {
+		t.Errorf("The account has not been updated: got version %v and %+v", printed.Version, printed.Attributes)
+	}
+
+	code, _, stderr = runAccountsWith("update", "--server-url", url, "--id", account.ID.String(), "--version", "0", "--bank-id", "400302")
+	if code != exitFailure || !strings.Contains(stderr, "the account has been modified") {
+		t.Errorf("Expected the version conflict to be reported, got %v: %v", code, stderr)
+	}
+}
+
+func TestAccountsUpdateClearsAFlag(t *testing.T) {
+	server, url := newFakeAPI(t)
+	account := newTestAccount()
+	account.Attributes.JointAccount = true
+	server.Add(account)
+
+	code, _, stderr := runAccountsWith("update", "--server-url", url, "--id", account.ID.String(), "--version", "0", "--joint-account=false")
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+	if stored := server.Accounts(); stored[0].Attributes.JointAccount || stored[0].OrganisationID != account.OrganisationID {
+		t.Errorf("Expected only the joint account flag to be cleared, got %+v", stored[0])
+	}
+}
+
+func TestAccountsDelete(t *testing.T) {
+	server, url := newFakeAPI(t)
+	account := newTestAccount()
+	server.Add(account)
+
+	code, _, stderr := runAccountsWith("delete", "--server-url", url, "--id", account.ID.String(), "--version", "1")
+	if code != exitFailure || !strings.Contains(stderr, "invalid version") {
+		t.Errorf("Expected the version conflict to be reported, got %v: %v", code, stderr)
+	}
+
+	code, stdout, stderr := runAccountsWith("delete", "--server-url", url, "--id", account.ID.String(), "--version", "0")
+	if code != exitOK || stderr != "" || stdout != "Account deleted succesfuly\n" {
+		t.Errorf("Expected the account to be deleted, got %v: %v %v", code, stdout, stderr)
+	}
+	if len(server.Accounts()) != 0 {
+		t.Errorf("The account has not been deleted")
+	}
+}
+
+func TestAccountsMandatoryFlags(t *testing.T) {
+	_, url := newFakeAPI(t)
+
+	tests := []struct {
+		args    []string
+		message string
+	}{
+		{[]string{"get"}, "--id must be a valid UUID"},
+		{[]string{"get", "--id", "42"}, "--id must be a valid UUID"},
+		{[]string{"update", "--version", "0", "--bank-id", "400300"}, "--id is mandatory to update an account"},
+		{[]string{"delete", "--version", "0"}, "--id must be a valid UUID"},
+		{[]string{"delete", "--id", uuid.New().String()}, "--version is mandatory to delete an account"},
+		{[]string{"update", "--id", uuid.New().String(), "--bank-id", "400300"}, "--version is mandatory to update an account"},
+	}
+
+	for _, test := range tests {
+		code, stdout, stderr := runAccountsWith(append(test.args, "--server-url", url)...)
+		if code != exitUsage || stdout != "" || !strings.Contains(stderr, test.message) {
+			t.Errorf("%v: expected exit code %v and %q but got %v: %v", test.args, exitUsage, test.message, code, stderr)
+		}
+	}
+}
+
+func TestAccountsUsage(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{}, exitUsage},
+		{[]string{"rename"}, exitUsage},
+		{[]string{"get", "--unknown"}, exitUsage},
+		{[]string{"get", "--id", uuid.New().String(), "--server-url", ""}, exitFailure},
+	}
+
+	for _, test := range tests {
+		code, stdout, stderr := runAccountsWith(test.args...)
+		if code != test.code || stdout != "" || stderr == "" {
+			t.Errorf("%v: expected exit code %v with an error but got %v: %v", test.args, test.code, code, stderr)
+		}
+	}
+
+	code, _, stderr := runAccountsWith("get", "--id", uuid.New().String(), "--server-url", "")
+	if !strings.Contains(stderr, "the server url is missing") {
+		t.Errorf("Expected the missing server url to be reported, got %v: %v", code, stderr)
+	}
+	code, _, _ = runAccountsWith("get", "--id", uuid.New().String(), "--server-url", "", "--timeout", "10")
+	if code != exitUsage {
+		t.Errorf("Expected a timeout without unit to be refused, got %v", code)
+	}
+}
+
+func TestAccountsUsageHidesTheCredentials(t *testing.T) {
+	t.Setenv("OAUTH_CLIENT_SECRET", "s3cr3t-value")
+	t.Setenv("SIGNING_PRIVATE_KEY", "/secrets/signing-key.pem")
+
+	for _, args := range [][]string{{"get", "--bogus"}, {"get"}, {"create", "--help"}, {"update", "--bogus"}} {
+		_, _, stderr := runAccountsWith(args...)
+		if strings.Contains(stderr, "s3cr3t-value") || strings.Contains(stderr, "signing-key.pem") {
+			t.Errorf("%v: the usage prints the credentials: %v", args, stderr)
+		}
+	}
+
+	// the environment is still used once the flags are parsed
+	code, _, stderr := runAccountsWith("get", "--id", uuid.New().String(), "--server-url", "http://localhost:8080")
+	if code != exitFailure || !strings.Contains(stderr, "both --key-id and --private-key are needed") {
+		t.Errorf("Expected the private key of the environment to be used, got %v: %v", code, stderr)
+	}
+}
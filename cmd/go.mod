@@ -1,6 +1,6 @@
-module main
+module form3-interview/cmd
 
-go 1.15
+go 1.21
 
 replace form3-interview/models => ../models
 
@@ -8,8 +8,13 @@ replace form3-interview/account => ../account
 
 replace form3-interview/httpclient => ../httpclient
 
+replace form3-interview/fakeapi => ../fakeapi
+
 require (
 	form3-interview/account v0.0.0-00010101000000-000000000000
+	form3-interview/httpclient v0.0.0-00010101000000-000000000000
 	form3-interview/models v0.0.0-00010101000000-000000000000
 	github.com/google/uuid v1.2.0
 )
+
+require form3-interview/fakeapi v0.0.0-00010101000000-000000000000
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"form3-interview/account"
+	"form3-interview/models"
+	"form3-interview/models/countryrules"
+
+	"github.com/google/uuid"
+)
+
+// runInteractive starts the menu driven console reading the options from in.
+// Every call goes through the client and is bounded by the timeout of the options.
+func runInteractive(client *account.Client, options *commandOptions, in io.Reader, out io.Writer) {
+	host := options.host
+
+	reader := &console{Reader: bufio.NewReader(in), out: out}
+	fmt.Fprintln(out, "Form3 Accounts API Console")
+	fmt.Fprintln(out, "---------------------")
+	fmt.Fprintln(out, "Select one of the following options and press enter:")
+	fmt.Fprintln(out, "1. Create a new account")
+	fmt.Fprintln(out, "2. Fetch an existing account")
+	fmt.Fprintln(out, "3. List of Accounts")
+	fmt.Fprintln(out, "4. Delete an account")
+	fmt.Fprintln(out, "5. Update an account")
+	fmt.Fprintln(out, "Enter to exit")
+
+	for {
+		fmt.Fprint(out, "-> ")
+		text, _ := reader.ReadString('\n')
+		// convert CRLF to LF
+		text = strings.Replace(text, "\n", "", -1)
+
+		if strings.Compare("1", text) == 0 {
+			fmt.Fprintln(out, "Create")
+			for {
+				var newAccountAttrs models.AccountAttributes
+
+				fmt.Fprint(out, "Country: ")
+				country, _ := reader.ReadString('\n')
+				country = strings.Replace(country, "\n", "", -1)
+				newAccountAttrs.Country = country
+
+				// only the fields supported by the country are asked
+				rule, _ := countryrules.Lookup(country)
+
+				fmt.Fprint(out, "BaseCurrency: ")
+				baseCurrency, _ := reader.ReadString('\n')
+				baseCurrency = strings.Replace(baseCurrency, "\n", "", -1)
+				newAccountAttrs.BaseCurrency = baseCurrency
+
+				newAccountAttrs.BankID = readRuleField(reader, "BankID", rule.BankID)
+
+				newAccountAttrs.BankIDCode = readRuleField(reader, "BankIDCode", rule.BankIDCode)
+
+				newAccountAttrs.Bic = readRuleField(reader, "Bic", rule.Bic)
+
+				newAccountAttrs.AccountNumber = readRuleField(reader, "AccountNumber", rule.AccountNumber)
+
+				fmt.Fprint(out, "CustomerID: ")
+				customerID, _ := reader.ReadString('\n')
+				customerID = strings.Replace(customerID, "\n", "", -1)
+				newAccountAttrs.CustomerID = customerID
+
+				newAccountAttrs.Name = readList(reader, "Name")
+
+				newAccountAttrs.AlternativeNames = readList(reader, "Alternative Names")
+
+				fmt.Fprint(out, "First Name: ")
+				firstName, _ := reader.ReadString('\n')
+				firstName = strings.Replace(firstName, "\n", "", -1)
+				newAccountAttrs.FirstName = firstName
+
+				fmt.Fprint(out, "BankAccountName: ")
+				bankAccountName, _ := reader.ReadString('\n')
+				bankAccountName = strings.Replace(bankAccountName, "\n", "", -1)
+				newAccountAttrs.BankAccountName = bankAccountName
+
+				fmt.Fprint(out, "Alternative Bank Account Names: ")
+				alternativeBankAccountNamesTxt, _ := reader.ReadString('\n')
+				alternativeBankAccountNamesTxt = strings.Replace(alternativeBankAccountNamesTxt, "\n", "", -1)
+				if alternativeBankAccountNamesTxt != "" {
+					alternativeBankAccountNamesSlice := []string(strings.Split(alternativeBankAccountNamesTxt, ","))
+					newAccountAttrs.AlternativeBankAccountNames = alternativeBankAccountNamesSlice
+				}
+
+				newAccountAttrs.Iban = readRuleField(reader, "Iban", rule.Iban)
+
+				fmt.Fprint(out, "Account Classification: ")
+				accountClassification, _ := reader.ReadString('\n')
+				accountClassification = strings.Replace(accountClassification, "\n", "", -1)
+				newAccountAttrs.AccountClassification = accountClassification
+
+				fmt.Fprint(out, "Joint Account: ")
+				jointAccountTxt, _ := reader.ReadString('\n')
+				jointAccountTxt = strings.Replace(jointAccountTxt, "\n", "", -1)
+				jointAccount, _ := strconv.ParseBool(jointAccountTxt)
+				newAccountAttrs.JointAccount = jointAccount
+
+				fmt.Fprint(out, "Switched: ")
+				switchedTxt, _ := reader.ReadString('\n')
+				switchedTxt = strings.Replace(switchedTxt, "\n", "", -1)
+				switched, _ := strconv.ParseBool(switchedTxt)
+				newAccountAttrs.Switched = switched
+
+				fmt.Fprint(out, "Account Matching OptOut: ")
+				accountMatchingOptOutTxt, _ := reader.ReadString('\n')
+				accountMatchingOptOutTxt = strings.Replace(accountMatchingOptOutTxt, "\n", "", -1)
+				accountMatchingOptOut, _ := strconv.ParseBool(accountMatchingOptOutTxt)
+				newAccountAttrs.AccountMatchingOptOut = accountMatchingOptOut
+
+				fmt.Fprint(out, "Secondary Identificationt: ")
+				secondaryIdentification, _ := reader.ReadString('\n')
+				secondaryIdentification = strings.Replace(secondaryIdentification, "\n", "", -1)
+				newAccountAttrs.SecondaryIdentification = secondaryIdentification
+
+				newAccountAttrs.NameMatchingStatus = readValue(reader, "Name Matching Status")
+				newAccountAttrs.ProcessingService = readValue(reader, "Processing Service")
+				newAccountAttrs.UserDefinedInformation = readValue(reader, "User Defined Information")
+				newAccountAttrs.ValidationType = readValue(reader, "Validation Type")
+				newAccountAttrs.ReferenceMask = readValue(reader, "Reference Mask")
+				newAccountAttrs.AcceptanceQualifier = readValue(reader, "Acceptance Qualifier")
+				newAccountAttrs.PrivateIdentification = readPrivateIdentification(reader)
+				newAccountAttrs.OrganisationIdentification = readOrganisationIdentification(reader)
+				newAccountAttrs.UserDefinedData = readUserDefinedData(reader)
+
+				var newAccount models.Account
+				newAccount.ID = uuid.New()
+				newAccount.Type = "accounts"
+				newAccount.OrganisationID = uuid.New()
+				newAccount.Attributes = &newAccountAttrs
+
+				var newData account.Data
+				newData.Account = &newAccount
+
+				var req account.CreateRequest
+				req.Host = host
+				req.Data = &newData
+
+				createAccount(client, options, req, out)
+				break
+			}
+		}
+		if strings.Compare("2", text) == 0 {
+			fmt.Fprintln(out, "Fecth")
+			for {
+				fmt.Fprint(out, "Account ID: ")
+				accountID, _ := reader.ReadString('\n')
+				accountID = strings.Replace(accountID, "\n", "", -1)
+
+				var req account.FetchRequest
+				var err error
+				req.AccountID, err = uuid.Parse(accountID)
+				if err != nil {
+					fmt.Fprintln(out, "Error: ", err)
+					break
+				}
+				req.Host = host
+				fecthAccount(client, options, req, out)
+				break
+			}
+		}
+		if strings.Compare("3", text) == 0 {
+			fmt.Fprintln(out, "List")
+			for {
+				fmt.Fprint(out, "Page Number: ")
+				pageNumber, _ := reader.ReadString('\n')
+				pageNumber = strings.Replace(pageNumber, "\n", "", -1)
+				pageNumberInt, _ := strconv.Atoi(pageNumber)
+
+				fmt.Fprint(out, "Page Size: ")
+				pageSize, _ := reader.ReadString('\n')
+				pageSize = strings.Replace(pageSize, "\n", "", -1)
+				pageSizeInt, _ := strconv.Atoi(pageSize)
+
+				var req account.ListRequest
+				req.PageNumber = pageNumberInt
+				req.PageSize = pageSizeInt
+				req.Host = host
+				accountList(client, options, req, out)
+				break
+			}
+
+		}
+		if strings.Compare("4", text) == 0 {
+			fmt.Fprintln(out, "Delete")
+			for {
+				fmt.Fprint(out, "Account ID: ")
+				accountIDTxt, _ := reader.ReadString('\n')
+				accountIDTxt = strings.Replace(accountIDTxt, "\n", "", -1)
+				accountID, err := uuid.Parse(accountIDTxt)
+				if err != nil {
+					fmt.Fprintln(out, "Error: ", err)
+					break
+				}
+
+				fmt.Fprint(out, "Version: ")
+				version, _ := reader.ReadString('\n')
+				version = strings.Replace(version, "\n", "", -1)
+				versionInt, _ := strconv.Atoi(version)
+
+				var req account.DeleteRequest
+				req.Host = host
+				req.AccountID = accountID
+				req.Version = versionInt
+				deleteAccount(client, options, req, out)
+				break
+			}
+
+		}
+		if strings.Compare("5", text) == 0 {
+			fmt.Fprintln(out, "Update")
+			for {
+				fmt.Fprint(out, "Account ID: ")
+				accountIDTxt, _ := reader.ReadString('\n')
+				accountIDTxt = strings.Replace(accountIDTxt, "\n", "", -1)
+				accountID, err := uuid.Parse(accountIDTxt)
+				if err != nil {
+					fmt.Fprintln(out, "Error: ", err)
+					break
+				}
+
+				fmt.Fprint(out, "Version: ")
+				version, _ := reader.ReadString('\n')
+				version = strings.Replace(version, "\n", "", -1)
+				versionInt, _ := strconv.Atoi(version)
+
+				fmt.Fprintln(out, "Leave a field empty to keep its current value")
+				var accountAttrs models.AccountAttributes
+
+				fmt.Fprint(out, "CustomerID: ")
+				customerID, _ := reader.ReadString('\n')
+				customerID = strings.Replace(customerID, "\n", "", -1)
+				accountAttrs.CustomerID = customerID
+
+				fmt.Fprint(out, "BankAccountName: ")
+				bankAccountName, _ := reader.ReadString('\n')
+				bankAccountName = strings.Replace(bankAccountName, "\n", "", -1)
+				accountAttrs.BankAccountName = bankAccountName
+
+				accountAttrs.Name = readList(reader, "Name")
+
+				fmt.Fprint(out, "Account Classification: ")
+				accountClassification, _ := reader.ReadString('\n')
+				accountClassification = strings.Replace(accountClassification, "\n", "", -1)
+				accountAttrs.AccountClassification = accountClassification
+
+				accountAttrs.NameMatchingStatus = readValue(reader, "Name Matching Status")
+
+				var updatedAccount models.Account
+				updatedAccount.ID = accountID
+				updatedAccount.Type = "accounts"
+				updatedAccount.Version = versionInt
+				updatedAccount.Attributes = &accountAttrs
+
+				var req account.UpdateRequest
+				req.Host = host
+				req.Data = &account.Data{Account: &updatedAccount}
+				updateAccount(client, options, req, out)
+				break
+			}
+
+		}
+		if strings.Compare("", text) == 0 {
+			fmt.Fprintln(out, "Bye!")
+			break
+		}
+	}
+
+}
+
+// console reads the answers of the user and writes the questions
+type console struct {
+	*bufio.Reader
+	out io.Writer
+}
+
+// readRuleField asks for a field governed by the rules of the country, showing its format.
+// Fields not supported by the country are skipped and a required field accepting a single value is set to it.
+func readRuleField(reader *console, label string, field countryrules.Field) string {
+	if field.Requirement == countryrules.NotSupported {
+		return ""
+	}
+	if field.Requirement == countryrules.Required && len(field.Values) == 1 {
+		fmt.Fprintf(reader.out, "%v: %v\n", label, field.Values[0])
+		return field.Values[0]
+	}
+
+	fmt.Fprintf(reader.out, "%v (%v): ", label, field.Hint())
+	value, _ := reader.ReadString('\n')
+	return strings.Replace(value, "\n", "", -1)
+}
+
+// readValue asks for a single value
+func readValue(reader *console, label string) string {
+	fmt.Fprintf(reader.out, "%v: ", label)
+	value, _ := reader.ReadString('\n')
+	return strings.Replace(value, "\n", "", -1)
+}
+
+// readList asks for comma separated values, nil when left empty
+func readList(reader *console, label string) []string {
+	value := readValue(reader, label+" (comma separated)")
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// readPrivateIdentification asks for the identification of a person, nil when left empty
+func readPrivateIdentification(reader *console) *models.PrivateIdentification {
+	identification := readValue(reader, "Private Identification")
+	if identification == "" {
+		return nil
+	}
+
+	return &models.PrivateIdentification{
+		Identification: identification,
+		BirthDate:      readValue(reader, "  Birth Date (YYYY-MM-DD)"),
+		BirthCountry:   readValue(reader, "  Birth Country"),
+		Address:        readList(reader, "  Address"),
+		City:           readValue(reader, "  City"),
+		Country:        readValue(reader, "  Country"),
+	}
+}
+
+// readOrganisationIdentification asks for the identification of an organisation and of its actors, nil when left empty
+func readOrganisationIdentification(reader *console) *models.OrganisationIdentification {
+	identification := readValue(reader, "Organisation Identification")
+	if identification == "" {
+		return nil
+	}
+
+	organisation := &models.OrganisationIdentification{
+		Identification: identification,
+		Address:        readList(reader, "  Address"),
+		City:           readValue(reader, "  City"),
+		Country:        readValue(reader, "  Country"),
+	}
+	for {
+		// the actors are asked until an empty name
+		name := readList(reader, "  Actor Name")
+		if name == nil {
+			return organisation
+		}
+		organisation.Actors = append(organisation.Actors, models.OrganisationActor{
+			Name:      name,
+			BirthDate: readValue(reader, "  Actor Birth Date (YYYY-MM-DD)"),
+			Residency: readValue(reader, "  Actor Residency"),
+		})
+	}
+}
+
+// readUserDefinedData asks for key=value pairs
+func readUserDefinedData(reader *console) []models.UserDefinedData {
+	var data []models.UserDefinedData
+	pairs := readValue(reader, "User Defined Data (comma separated key=value)")
+	if pairs == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(pairs, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		data = append(data, models.UserDefinedData{Key: key, Value: value})
+	}
+	return data
+}
+
+func createAccount(client *account.Client, options *commandOptions, req account.CreateRequest, out io.Writer) {
+	// the account is checked before sending it so that every invalid field is reported at once
+	if err := req.Data.Account.Validate(); err != nil {
+		printValidationErrors(out, err)
+		return
+	}
+
+	ctx, cancel := options.context()
+	defer cancel()
+
+	resp, err := client.Create(ctx, &req)
+	if err != nil {
+		fmt.Fprintln(out, "Error: ", err)
+	} else {
+		err := printAccounts(out, &options.output, []models.Account{*resp}, true)
+		if err != nil {
+			fmt.Fprintln(out, "Error: ", err)
+		}
+	}
+}
+
+func accountList(client *account.Client, options *commandOptions, req account.ListRequest, out io.Writer) {
+	ctx, cancel := options.context()
+	defer cancel()
+
+	resp, err := client.List(ctx, &req)
+	if err != nil {
+		fmt.Fprintln(out, "Error: ", err)
+	} else {
+		err := printAccounts(out, &options.output, resp, false)
+		if err != nil {
+			fmt.Fprintln(out, "Error: ", err)
+		}
+	}
+}
+
+func deleteAccount(client *account.Client, options *commandOptions, req account.DeleteRequest, out io.Writer) {
+	ctx, cancel := options.context()
+	defer cancel()
+
+	err := client.Delete(ctx, &req)
+	if err != nil {
+		fmt.Fprintln(out, "Error: ", err)
+	} else {
+		fmt.Fprintln(out, "Account deleted succesfuly")
+	}
+}
+
+func fecthAccount(client *account.Client, options *commandOptions, req account.FetchRequest, out io.Writer) {
+	ctx, cancel := options.context()
+	defer cancel()
+
+	resp, err := client.Fetch(ctx, &req)
+	if err != nil {
+		fmt.Fprintln(out, "Error: ", err)
+	} else {
+		err := printAccounts(out, &options.output, []models.Account{*resp}, true)
+		if err != nil {
+			fmt.Fprintln(out, "Error: ", err)
+		}
+	}
+}
+
+func updateAccount(client *account.Client, options *commandOptions, req account.UpdateRequest, out io.Writer) {
+	ctx, cancel := options.context()
+	defer cancel()
+
+	resp, err := client.Update(ctx, &req)
+	if errors.Is(err, account.ErrVersionConflict) {
+		fmt.Fprintln(out, "Error: the account has been modified, fetch it again to get the current version")
+	} else if err != nil {
+		fmt.Fprintln(out, "Error: ", err)
+	} else {
+		err := printAccounts(out, &options.output, []models.Account{*resp}, true)
+		if err != nil {
+			fmt.Fprintln(out, "Error: ", err)
+		}
+	}
+}
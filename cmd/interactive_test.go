@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"form3-interview/fakeapi"
+	"form3-interview/httpclient"
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+// newFakeAPI starts an in memory account API and returns it with its url
+func newFakeAPI(t *testing.T) (*fakeapi.Server, string) {
+	server := fakeapi.New()
+	testServer := httptest.NewServer(server)
+	t.Cleanup(testServer.Close)
+	return server, testServer.URL
+}
+
+// newTestAccount returns a valid account
+func newTestAccount() models.Account {
+	return models.Account{
+		Type:           "accounts",
+		ID:             uuid.New(),
+		OrganisationID: uuid.New(),
+		Attributes: &models.AccountAttributes{
+			Country:      "GB",
+			BaseCurrency: "GBP",
+			BankID:       "400300",
+			BankIDCode:   "GBDSC",
+			Bic:          "NWBKGB22",
+		},
+	}
+}
+
+// runInteractiveWith runs the interactive console answering with input
+func runInteractiveWith(t *testing.T, input string, args ...string) (int, string, string) {
+	previous := stdin
+	stdin = strings.NewReader(input)
+	t.Cleanup(func() { stdin = previous })
+
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"interactive"}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestInteractiveFetch(t *testing.T) {
+	server, url := newFakeAPI(t)
+	account := newTestAccount()
+	server.Add(account)
+
+	code, stdout, stderr := runInteractiveWith(t, "2\n"+account.ID.String()+"\n\n", "--server-url", url)
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, account.ID.String()) || !strings.Contains(stdout, "Bye!") {
+		t.Errorf("Expected the account to be printed, got %v", stdout)
+	}
+}
+
+func TestInteractiveAppliesTheTimeout(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer func() { testServer.Close() }()
+
+	start := time.Now()
+	code, stdout, _ := runInteractiveWith(t, "3\n0\n10\n\n", "--server-url", testServer.URL, "--timeout", "100ms")
+	if code != exitOK || !strings.Contains(stdout, "context deadline exceeded") {
+		t.Errorf("Expected the list to time out, got %v: %v", code, stdout)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("The timeout has not been applied, the console took %v", elapsed)
+	}
+}
+
+func TestInteractiveNeedsTheServerURL(t *testing.T) {
+	code, _, stderr := runInteractiveWith(t, "\n", "--server-url", "")
+	if code != exitFailure || !strings.Contains(stderr, "the server url is missing") {
+		t.Errorf("Expected the missing server url to be reported, got %v: %v", code, stderr)
+	}
+}
+
+func TestInteractiveSignsRequests(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	if err := ioutil.WriteFile(keyFile, pemKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := &httpclient.Verifier{Keys: map[string]*rsa.PublicKey{"key-id": &privateKey.PublicKey}}
+	verifyErr := errors.New("no request received")
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		verifyErr = verifier.Verify(req, nil)
+		res.Write([]byte(`{"data":[]}`))
+	}))
+	defer func() { testServer.Close() }()
+
+	code, _, stderr := runInteractiveWith(t, "3\n0\n10\n\n", "--server-url", testServer.URL, "--key-id", "key-id", "--private-key", keyFile)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %v but got %v: %v", exitOK, code, stderr)
+	}
+	if verifyErr != nil {
+		t.Errorf("Request not signed correctly: got %v", verifyErr)
+	}
+
+	code, _, stderr = runInteractiveWith(t, "\n", "--server-url", testServer.URL, "--key-id", "key-id", "--private-key", "")
+	if code != exitFailure || !strings.Contains(stderr, "both --key-id and --private-key are needed") {
+		t.Errorf("Expected the missing private key to be reported, got %v: %v", code, stderr)
+	}
+}
+
+func TestInteractiveUsesClientCredentials(t *testing.T) {
+	tokens := fakeapi.NewTokenServer("client", "s3cret")
+	server := fakeapi.New()
+	account := newTestAccount()
+	server.Add(account)
+	mux := http.NewServeMux()
+	mux.Handle(fakeapi.TokenPath, tokens)
+	mux.Handle("/", tokens.Protect(server))
+	testServer := httptest.NewServer(mux)
+	defer func() { testServer.Close() }()
+
+	code, stdout, stderr := runInteractiveWith(t, "2\n"+account.ID.String()+"\n\n", "--server-url", testServer.URL,
+		"--token-url", testServer.URL+fakeapi.TokenPath, "--client-id", "client", "--client-secret", "s3cret")
+	if code != exitOK || stderr != "" {
+		t.Fatalf("Expected exit code %v without error but got %v: %v", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, account.ID.String()) || tokens.Issued() != 1 {
+		t.Errorf("Expected the account to be fetched with a bearer token, got %v tokens issued: %v", tokens.Issued(), stdout)
+	}
+}
+
+func TestInteractiveLogsTheCalls(t *testing.T) {
+	server, url := newFakeAPI(t)
+	account := newTestAccount()
+	server.Add(account)
+
+	code, _, stderr := runInteractiveWith(t, "2\n"+account.ID.String()+"\n\n", "--server-url", url, "--verbose")
+	if code != exitOK || !strings.Contains(stderr, account.ID.String()) {
+		t.Errorf("Expected the fetch to be logged on stderr, got %v: %v", code, stderr)
+	}
+
+	code, _, stderr = runInteractiveWith(t, "2\n"+account.ID.String()+"\n\n", "--server-url", url, "--log-bodies")
+	if code != exitOK || !strings.Contains(stderr, "NWBKGB22") {
+		t.Errorf("Expected the body of the fetch to be logged on stderr, got %v: %v", code, stderr)
+	}
+}
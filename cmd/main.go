@@ -1,266 +1,140 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
-	"strings"
-
-	"form3-interview/account"
-	"form3-interview/models"
-
-	"github.com/google/uuid"
+	"time"
 )
 
-func main() {
-	serverURL := os.Getenv("SERVER_URL")
-	host := os.Getenv("HOST")
-
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("Form3 Accounts API Console")
-	fmt.Println("---------------------")
-	fmt.Println("Select one of the following options and press enter:")
-	fmt.Println("1. Create a new account")
-	fmt.Println("2. Fetch an existing account")
-	fmt.Println("3. List of Accounts")
-	fmt.Println("4. Delete an account")
-	fmt.Println("Enter to exit")
-
-	for {
-		fmt.Print("-> ")
-		text, _ := reader.ReadString('\n')
-		// convert CRLF to LF
-		text = strings.Replace(text, "\n", "", -1)
-
-		if strings.Compare("1", text) == 0 {
-			fmt.Println("Create")
-			for {
-				var newAccountAttrs models.AccountAttributes
-
-				fmt.Print("Country: ")
-				country, _ := reader.ReadString('\n')
-				country = strings.Replace(country, "\n", "", -1)
-				newAccountAttrs.Country = country
-
-				fmt.Print("BaseCurrency: ")
-				baseCurrency, _ := reader.ReadString('\n')
-				baseCurrency = strings.Replace(baseCurrency, "\n", "", -1)
-				newAccountAttrs.BaseCurrency = baseCurrency
-
-				fmt.Print("BankID: ")
-				bankID, _ := reader.ReadString('\n')
-				bankID = strings.Replace(bankID, "\n", "", -1)
-				newAccountAttrs.BankID = bankID
-
-				fmt.Print("BankIDCode: ")
-				bankIDCode, _ := reader.ReadString('\n')
-				bankIDCode = strings.Replace(bankIDCode, "\n", "", -1)
-				newAccountAttrs.BankIDCode = bankIDCode
-
-				fmt.Print("Bic: ")
-				bic, _ := reader.ReadString('\n')
-				bic = strings.Replace(bic, "\n", "", -1)
-				newAccountAttrs.Bic = bic
-
-				fmt.Print("AccountNumber: ")
-				accountNumber, _ := reader.ReadString('\n')
-				accountNumber = strings.Replace(accountNumber, "\n", "", -1)
-				newAccountAttrs.AccountNumber = accountNumber
-
-				fmt.Print("CustomerID: ")
-				customerID, _ := reader.ReadString('\n')
-				customerID = strings.Replace(customerID, "\n", "", -1)
-				newAccountAttrs.CustomerID = customerID
-
-				fmt.Print("First Name: ")
-				firstName, _ := reader.ReadString('\n')
-				firstName = strings.Replace(firstName, "\n", "", -1)
-				newAccountAttrs.FirstName = firstName
-
-				fmt.Print("BankAccountName: ")
-				bankAccountName, _ := reader.ReadString('\n')
-				bankAccountName = strings.Replace(bankAccountName, "\n", "", -1)
-				newAccountAttrs.BankAccountName = bankAccountName
-
-				fmt.Print("Alternative Bank Account Names: ")
-				alternativeBankAccountNamesTxt, _ := reader.ReadString('\n')
-				alternativeBankAccountNamesTxt = strings.Replace(alternativeBankAccountNamesTxt, "\n", "", -1)
-				alternativeBankAccountNamesSlice := []string(strings.Split(alternativeBankAccountNamesTxt, ","))
-				newAccountAttrs.AlternativeBankAccountNames = alternativeBankAccountNamesSlice
-
-				fmt.Print("Iban: ")
-				iban, _ := reader.ReadString('\n')
-				iban = strings.Replace(iban, "\n", "", -1)
-				newAccountAttrs.Iban = iban
-
-				fmt.Print("Account Classification: ")
-				accountClassification, _ := reader.ReadString('\n')
-				accountClassification = strings.Replace(accountClassification, "\n", "", -1)
-				newAccountAttrs.AccountClassification = accountClassification
-
-				fmt.Print("Joint Account: ")
-				jointAccountTxt, _ := reader.ReadString('\n')
-				jointAccountTxt = strings.Replace(jointAccountTxt, "\n", "", -1)
-				jointAccount, _ := strconv.ParseBool(jointAccountTxt)
-				newAccountAttrs.JointAccount = jointAccount
-
-				fmt.Print("Switched: ")
-				switchedTxt, _ := reader.ReadString('\n')
-				switchedTxt = strings.Replace(switchedTxt, "\n", "", -1)
-				switched, _ := strconv.ParseBool(switchedTxt)
-				newAccountAttrs.Switched = switched
-
-				fmt.Print("Account Matching OptOut: ")
-				accountMatchingOptOutTxt, _ := reader.ReadString('\n')
-				accountMatchingOptOutTxt = strings.Replace(accountMatchingOptOutTxt, "\n", "", -1)
-				accountMatchingOptOut, _ := strconv.ParseBool(accountMatchingOptOutTxt)
-				newAccountAttrs.AccountMatchingOptOut = accountMatchingOptOut
-
-				fmt.Print("Secondary Identificationt: ")
-				secondaryIdentification, _ := reader.ReadString('\n')
-				secondaryIdentification = strings.Replace(secondaryIdentification, "\n", "", -1)
-				newAccountAttrs.SecondaryIdentification = secondaryIdentification
-
-				var newAccount models.Account
-				newAccount.ID = uuid.New()
-				newAccount.Type = "accounts"
-				newAccount.OrganisationID = uuid.New()
-				newAccount.Attributes = &newAccountAttrs
-
-				var newData account.Data
-				newData.Account = &newAccount
+// exit codes returned by the command line
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
 
-				var req account.CreateRequest
-				req.Host = host
-				req.Data = &newData
+const usage = `Form3 Accounts API command line
 
-				createAccount(serverURL, req)
-				break
-			}
-		}
-		if strings.Compare("2", text) == 0 {
-			fmt.Println("Fecth")
-			for {
-				fmt.Print("Account ID: ")
-				accountID, _ := reader.ReadString('\n')
-				accountID = strings.Replace(accountID, "\n", "", -1)
+Usage:
+  main accounts <create|get|list|update|delete> [flags]
+  main interactive [flags]
 
-				var req account.FetchRequest
-				var err error
-				req.AccountID, err = uuid.Parse(accountID)
-				if err != nil {
-					fmt.Println("Error: ", err)
-					break
-				}
-				req.Host = host
-				fecthAccount(host, serverURL, req)
-				break
-			}
-		}
-		if strings.Compare("3", text) == 0 {
-			fmt.Println("List")
-			for {
-				fmt.Print("Page Number: ")
-				pageNumber, _ := reader.ReadString('\n')
-				pageNumber = strings.Replace(pageNumber, "\n", "", -1)
-				pageNumberInt, _ := strconv.Atoi(pageNumber)
+Every command accepts --server-url and --host, defaulting to the
+SERVER_URL and HOST environment variables, and --timeout. In the
+interactive console the timeout bounds every call to the API.
+Run "main accounts <command> --help" to list the flags of a command.
+Without a command the interactive console is started.
+`
 
-				fmt.Print("Page Size: ")
-				pageSize, _ := reader.ReadString('\n')
-				pageSize = strings.Replace(pageSize, "\n", "", -1)
-				pageSizeInt, _ := strconv.Atoi(pageSize)
+// stdin is read by the interactive console and by --file -
+var stdin io.Reader = os.Stdin
 
-				var req account.ListRequest
-				req.PageNumber = pageNumberInt
-				req.PageSize = pageSizeInt
-				req.Host = host
-				accountList(serverURL, req)
-				break
-			}
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-		}
-		if strings.Compare("4", text) == 0 {
-			fmt.Println("Delete")
-			for {
-				fmt.Print("Account ID: ")
-				accountIDTxt, _ := reader.ReadString('\n')
-				accountIDTxt = strings.Replace(accountIDTxt, "\n", "", -1)
-				accountID, err := uuid.Parse(accountIDTxt)
-				if err != nil {
-					fmt.Println("Error: ", err)
-					break
-				}
+// run executes the command in args and returns the exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		return runInteractiveCommand(args, stdout, stderr)
+	}
 
-				fmt.Print("Version: ")
-				version, _ := reader.ReadString('\n')
-				version = strings.Replace(version, "\n", "", -1)
-				versionInt, _ := strconv.Atoi(version)
+	switch args[0] {
+	case "accounts":
+		return runAccounts(args[1:], stdout, stderr)
+	case "interactive":
+		return runInteractiveCommand(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "Unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+}
 
-				var req account.DeleteRequest
-				req.Host = host
-				req.AccountID = accountID
-				req.Version = versionInt
-				deleteAccount(host, serverURL, req)
-				break
-			}
+func runInteractiveCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	var options commandOptions
+	flags := newFlagSet("interactive", &options, stderr)
+	addOutputFlags(flags, &options.output)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if err := options.output.validate(); err != nil {
+		return usageError(stderr, flags, err.Error())
+	}
 
-		}
-		if strings.Compare("", text) == 0 {
-			fmt.Println("Bye!")
-			break
-		}
+	client, err := newClient(&options)
+	if err != nil {
+		return fail(stderr, err)
 	}
 
+	runInteractive(client, &options, stdin, stdout)
+	return exitOK
 }
 
-func createAccount(serverURL string, req account.CreateRequest) {
-	resp, err := account.CreateAccount(serverURL, &req)
-	if err != nil {
-		fmt.Println("Error: ", err)
-	} else {
-		body, err := json.MarshalIndent(resp, "", "  ")
-		if err != nil {
-			fmt.Println("Error: ", err)
-		}
-		fmt.Println(string(body))
-	}
+// commandOptions are the flags shared by every command
+type commandOptions struct {
+	serverURL string
+	host      string
+	keyID     string
+	keyFile   string
+	oauth     oauthOptions
+	timeout   time.Duration
+	output    outputOptions
+	logging   loggingOptions
 }
 
-func accountList(serverURL string, req account.ListRequest) {
-	resp, err := account.GetAccountList(serverURL, &req)
-	if err != nil {
-		fmt.Println("Error: ", err)
-	} else {
-		body, err := json.MarshalIndent(resp, "", "  ")
-		if err != nil {
-			fmt.Println("Error: ", err)
-		}
-		fmt.Println(string(body))
-	}
+// loggingOptions enable the logs of the API calls
+type loggingOptions struct {
+	verbose bool
+	bodies  bool
+	output  io.Writer
 }
 
-func deleteAccount(host string, serverURL string, req account.DeleteRequest) {
+// oauthOptions are the OAuth2 client credentials used to get a bearer token
+type oauthOptions struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+}
 
-	err := account.DeleteAccount(serverURL, &req)
-	if err != nil {
-		fmt.Println("Error: ", err)
-	} else {
-		fmt.Println("Account deleted succesfuly")
+// context returns the context bounding the execution of a command
+func (o *commandOptions) context() (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return context.WithCancel(context.Background())
 	}
+	return context.WithTimeout(context.Background(), o.timeout)
 }
 
-func fecthAccount(host string, serverURL string, req account.FetchRequest) {
-	resp, err := account.GetAccount(serverURL, &req)
-
-	if err != nil {
-		fmt.Println("Error: ", err)
-	} else {
-		body, err := json.MarshalIndent(resp, "", "  ")
-		if err != nil {
-			fmt.Println("Error: ", err)
-		}
-		fmt.Println(string(body))
+// credentialsFromEnvironment sets the credentials not given on the command line from the environment
+func (o *commandOptions) credentialsFromEnvironment() {
+	if o.keyFile == "" {
+		o.keyFile = os.Getenv("SIGNING_PRIVATE_KEY")
+	}
+	if o.oauth.clientSecret == "" {
+		o.oauth.clientSecret = os.Getenv("OAUTH_CLIENT_SECRET")
 	}
 }
+
+// newFlagSet creates the flags of a command, including the shared ones
+func newFlagSet(name string, options *commandOptions, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&options.serverURL, "server-url", os.Getenv("SERVER_URL"), "base url of the API, defaults to $SERVER_URL")
+	flags.StringVar(&options.host, "host", os.Getenv("HOST"), "Host header sent to the API, defaults to $HOST")
+	flags.StringVar(&options.keyID, "key-id", os.Getenv("SIGNING_KEY_ID"), "ID of the key signing the requests, defaults to $SIGNING_KEY_ID")
+	// the credentials default to the environment after parsing, the usage must not print them
+	flags.StringVar(&options.keyFile, "private-key", "", "PEM file of the RSA key signing the requests, defaults to $SIGNING_PRIVATE_KEY")
+	flags.StringVar(&options.oauth.tokenURL, "token-url", os.Getenv("OAUTH_TOKEN_URL"), "OAuth2 token endpoint, defaults to $OAUTH_TOKEN_URL")
+	flags.StringVar(&options.oauth.clientID, "client-id", os.Getenv("OAUTH_CLIENT_ID"), "OAuth2 client ID, defaults to $OAUTH_CLIENT_ID")
+	flags.StringVar(&options.oauth.clientSecret, "client-secret", "", "OAuth2 client secret, defaults to $OAUTH_CLIENT_SECRET")
+	flags.DurationVar(&options.timeout, "timeout", time.Minute, "maximum duration of the command including retries, 0 for no limit")
+	flags.BoolVar(&options.logging.verbose, "verbose", false, "log every call to the API on stderr")
+	flags.BoolVar(&options.logging.bodies, "log-bodies", false, "log the bodies of the calls, without personal data, implies --verbose")
+	options.logging.output = stderr
+	return flags
+}
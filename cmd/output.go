@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"form3-interview/models"
+)
+
+// output formats accepted by --output
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatYAML   = "yaml"
+	formatTable  = "table"
+	formatCSV    = "csv"
+)
+
+// outputOptions are the flags controlling how accounts are printed
+type outputOptions struct {
+	format string
+	fields string
+}
+
+// column is a value extracted from an account, identified by its JSON path
+type column struct {
+	header string
+	path   string
+}
+
+// defaultColumns are printed by the table and csv formats when --fields is not set
+var defaultColumns = []column{
+	{"ID", "id"},
+	{"VERSION", "version"},
+	{"COUNTRY", "attributes.country"},
+	{"BANK ID", "attributes.bank_id"},
+	{"ACCOUNT NUMBER", "attributes.account_number"},
+	{"STATUS", "attributes.status"},
+}
+
+// addOutputFlags adds --output and --fields to a command printing accounts
+func addOutputFlags(flags *flag.FlagSet, output *outputOptions) {
+	flags.StringVar(&output.format, "output", formatJSON, "output format: json, yaml, table, csv or ndjson")
+	flags.StringVar(&output.fields, "fields", "", "comma separated JSON paths to print, e.g. id,attributes.iban, or a Go template, e.g. '{{.ID}} {{.Attributes.Iban}}'")
+}
+
+// validate checks the output flags before the command is executed
+func (o *outputOptions) validate() error {
+	switch o.format {
+	case formatJSON, formatNDJSON, formatYAML, formatTable, formatCSV:
+	default:
+		return fmt.Errorf("unknown output format %q", o.format)
+	}
+
+	if o.isTemplate() {
+		_, err := template.New("fields").Parse(o.fields)
+		return err
+	}
+	return nil
+}
+
+func (o *outputOptions) isTemplate() bool {
+	return strings.Contains(o.fields, "{{")
+}
+
+// columns returns the columns selected by --fields or the default ones
+func (o *outputOptions) columns() []column {
+	if o.fields == "" {
+		return defaultColumns
+	}
+
+	var columns []column
+	for _, field := range strings.Split(o.fields, ",") {
+		path := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(field), "$"), ".")
+		if path != "" {
+			columns = append(columns, column{header: path, path: path})
+		}
+	}
+	return columns
+}
+
+// printAccounts writes the accounts in the format selected by the output options.
+// single is set when the command returns one account, it is then printed as an object rather than a list.
+func printAccounts(w io.Writer, output *outputOptions, accounts []models.Account, single bool) error {
+	if output.isTemplate() {
+		return printTemplate(w, output.fields, accounts)
+	}
+
+	// the documents mirror the JSON representation of the accounts
+	documents, err := toDocuments(accounts)
+	if err != nil {
+		return err
+	}
+
+	if output.fields != "" && output.format != formatTable && output.format != formatCSV {
+		documents = project(documents, output.columns())
+	}
+
+	var value interface{} = documents
+	if single && len(documents) == 1 {
+		value = documents[0]
+	}
+
+	switch output.format {
+	case formatTable:
+		return printTable(w, output.columns(), documents)
+	case formatCSV:
+		return printCSV(w, output.columns(), documents)
+	case formatNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, document := range documents {
+			if err := encoder.Encode(document); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatYAML:
+		return writeYAML(w, value, 0)
+	default:
+		body, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(body))
+		return err
+	}
+}
+
+func printTemplate(w io.Writer, text string, accounts []models.Account) error {
+	tmpl, err := template.New("fields").Parse(text)
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		if err := tmpl.Execute(w, account); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func printTable(w io.Writer, columns []column, documents []interface{}) error {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(column.header)
+	}
+	fmt.Fprintln(table, strings.Join(headers, "\t"))
+
+	for _, document := range documents {
+		fmt.Fprintln(table, strings.Join(row(columns, document), "\t"))
+	}
+	return table.Flush()
+}
+
+func printCSV(w io.Writer, columns []column, documents []interface{}) error {
+	writer := csv.NewWriter(w)
+
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.path
+	}
+	writer.Write(headers)
+
+	for _, document := range documents {
+		writer.Write(row(columns, document))
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// toDocuments converts the accounts to their generic JSON representation
+func toDocuments(accounts []models.Account) ([]interface{}, error) {
+	body, err := json.Marshal(accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := []interface{}{}
+	err = json.Unmarshal(body, &documents)
+	return documents, err
+}
+
+// project keeps only the selected paths of every document
+func project(documents []interface{}, columns []column) []interface{} {
+	projected := make([]interface{}, len(documents))
+	for i, document := range documents {
+		fields := make(map[string]interface{})
+		for _, column := range columns {
+			fields[column.path] = lookup(document, column.path)
+		}
+		projected[i] = fields
+	}
+	return projected
+}
+
+func row(columns []column, document interface{}) []string {
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = formatValue(lookup(document, column.path))
+	}
+	return values
+}
+
+// lookup returns the value at a dot separated path, e.g. attributes.alternative_bank_account_names[0]
+func lookup(document interface{}, path string) interface{} {
+	value := document
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		index := -1
+		if open := strings.Index(part, "["); open >= 0 && strings.HasSuffix(part, "]") {
+			name = part[:open]
+			i, err := strconv.Atoi(part[open+1 : len(part)-1])
+			if err != nil {
+				return nil
+			}
+			index = i
+		}
+
+		if name != "" {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			value = object[name]
+		}
+
+		if index >= 0 {
+			list, ok := value.([]interface{})
+			if !ok || index >= len(list) {
+				return nil
+			}
+			value = list[index]
+		}
+	}
+	return value
+}
+
+// formatValue prints a JSON value in a single table or csv cell
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = formatValue(item)
+		}
+		return strings.Join(values, ",")
+	case map[string]interface{}:
+		body, _ := json.Marshal(v)
+		return string(body)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeYAML writes a generic JSON value as YAML
+func writeYAML(w io.Writer, value interface{}, indent int) error {
+	padding := strings.Repeat("  ", indent)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			_, err := fmt.Fprintln(w, padding+"{}")
+			return err
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := writeYAMLEntry(w, padding+yamlScalar(key)+":", v[key], indent); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			_, err := fmt.Fprintln(w, padding+"[]")
+			return err
+		}
+		for _, item := range v {
+			// a mapping starts on the same line as its dash
+			if object, ok := item.(map[string]interface{}); ok && len(object) > 0 {
+				var nested strings.Builder
+				writeYAML(&nested, object, indent+1)
+				_, err := io.WriteString(w, padding+"- "+strings.TrimPrefix(nested.String(), padding+"  "))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err := writeYAMLEntry(w, padding+"-", item, indent); err != nil {
+				return err
+			}
+		}
+	default:
+		_, err := fmt.Fprintln(w, padding+yamlScalar(v))
+		return err
+	}
+	return nil
+}
+
+// writeYAMLEntry writes a key or list item followed by its value, nested when it is not a scalar
+func writeYAMLEntry(w io.Writer, prefix string, value interface{}, indent int) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			fmt.Fprintln(w, prefix)
+			return writeYAML(w, v, indent+1)
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			fmt.Fprintln(w, prefix)
+			return writeYAML(w, v, indent+1)
+		}
+	}
+
+	var scalar string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		scalar = "{}"
+	case []interface{}:
+		scalar = "[]"
+	default:
+		scalar = yamlScalar(v)
+	}
+	_, err := fmt.Fprintln(w, prefix+" "+scalar)
+	return err
+}
+
+// yamlScalar formats a scalar, quoting strings that YAML would read as something else
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		if needsQuoting(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func needsQuoting(value string) bool {
+	if value == "" || strings.TrimSpace(value) != value {
+		return true
+	}
+
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return true
+	}
+
+	if strings.ContainsAny(value[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	return strings.Contains(value, ": ") || strings.Contains(value, " #") || strings.ContainsAny(value, "\n\t")
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"form3-interview/models"
+)
+
+func TestYAMLScalar(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{"plain", "plain"},
+		{"key:value", "key:value"},
+		{"key: value", `"key: value"`},
+		{":key", `":key"`},
+		{"a#b", "a#b"},
+		{"a #b", `"a #b"`},
+		{"#comment", `"#comment"`},
+		{" leading", `" leading"`},
+		{"trailing ", `"trailing "`},
+		{"null", `"null"`},
+		{"Null", `"Null"`},
+		{"~", `"~"`},
+		{"yes", `"yes"`},
+		{"123", `"123"`},
+		{"-dash", `"-dash"`},
+		{"two\nlines", `"two\nlines"`},
+		{"", `""`},
+		{nil, "null"},
+		{true, "true"},
+		{float64(3), "3"},
+	}
+
+	for _, test := range tests {
+		if scalar := yamlScalar(test.value); scalar != test.expected {
+			t.Errorf("YAML scalar of %q: expected %v but got %v", test.value, test.expected, scalar)
+		}
+	}
+}
+
+func TestPrintAccountsYAML(t *testing.T) {
+	account := newTestAccount()
+	account.Attributes.Name = []string{" Smith", "null"}
+	account.Attributes.SecondaryIdentification = "ref: 1 #2"
+
+	var out bytes.Buffer
+	output := outputOptions{format: formatYAML}
+	if err := printAccounts(&out, &output, []models.Account{account}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"id: " + account.ID.String(),
+		"attributes:\n",
+		`  secondary_identification: "ref: 1 #2"`,
+		"  name:\n" + `    - " Smith"` + "\n" + `    - "null"` + "\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected %q in the YAML output, got\n%v", line, out.String())
+		}
+	}
+}
+
+func TestPrintAccountsCSV(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"Smith, John", `"Smith, John"`},
+		{`The "Best"`, `"The ""Best"""`},
+		{"two\nlines", "\"two\nlines\""},
+		{" leading", `" leading"`},
+	}
+
+	for _, test := range tests {
+		account := newTestAccount()
+		account.Attributes.SecondaryIdentification = test.value
+
+		var out bytes.Buffer
+		output := outputOptions{format: formatCSV, fields: "id,attributes.secondary_identification"}
+		if err := printAccounts(&out, &output, []models.Account{account}, false); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "id,attributes.secondary_identification\n" + account.ID.String() + "," + test.expected + "\n"
+		if out.String() != expected {
+			t.Errorf("CSV of %q: expected %q but got %q", test.value, expected, out.String())
+		}
+	}
+}
+
+func TestPrintAccountsNestedFields(t *testing.T) {
+	account := newTestAccount()
+	account.Attributes.Name = []string{"Jane", "Doe"}
+
+	tests := []struct {
+		fields   string
+		expected string
+	}{
+		{"attributes.country", "GB"},
+		{"$.attributes.bank_id", "400300"},
+		{".attributes.bic", "NWBKGB22"},
+		{"attributes.name", "Jane,Doe"},
+		{"attributes.name[1]", "Doe"},
+		{"attributes.name[2]", ""},
+		{"attributes.name[x]", ""},
+		{"attributes.country.code", ""},
+		{"attributes.missing", ""},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		output := outputOptions{format: formatCSV, fields: test.fields}
+		if err := printAccounts(&out, &output, []models.Account{account}, false); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		if len(lines) != 2 || strings.Trim(lines[1], `"`) != test.expected {
+			t.Errorf("Fields %v: expected %q but got %q", test.fields, test.expected, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	output := outputOptions{format: formatJSON, fields: "id,attributes.name[0]"}
+	if err := printAccounts(&out, &output, []models.Account{account}, true); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"attributes.name[0]\": \"Jane\",\n  \"id\": \"" + account.ID.String() + "\"\n}\n"
+	if out.String() != expected {
+		t.Errorf("Expected the JSON to be projected on the fields, got %v", out.String())
+	}
+}
+
+func TestOutputTemplates(t *testing.T) {
+	tests := []struct {
+		fields  string
+		valid   bool
+		printed string
+	}{
+		{"{{.Attributes.Country}} {{.Attributes.BankID}}", true, "GB 400300\n"},
+		{"{{.Attributes.Country", false, ""},
+		{"{{.Attributes.Country}", false, ""},
+		{"{{if .ID}}", false, ""},
+		{"{{.Missing}}", true, ""},
+	}
+
+	for _, test := range tests {
+		output := outputOptions{format: formatJSON, fields: test.fields}
+		if err := output.validate(); (err == nil) != test.valid {
+			t.Errorf("Template %v: expected valid %t but got %v", test.fields, test.valid, err)
+		}
+		if !test.valid {
+			continue
+		}
+
+		var out bytes.Buffer
+		err := printAccounts(&out, &output, []models.Account{newTestAccount()}, true)
+		if test.printed == "" && err == nil {
+			t.Errorf("Template %v: expected an error executing it", test.fields)
+		}
+		if test.printed != "" && out.String() != test.printed {
+			t.Errorf("Template %v: expected %q but got %q, %v", test.fields, test.printed, out.String(), err)
+		}
+	}
+
+	output := outputOptions{format: "xml"}
+	if err := output.validate(); err == nil || err.Error() != `unknown output format "xml"` {
+		t.Errorf("Expected the unknown format to be refused, got %v", err)
+	}
+}
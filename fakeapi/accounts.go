@@ -0,0 +1,304 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+// document is the body of the requests and responses of a single account
+type document struct {
+	Data  *models.Account `json:"data"`
+	Links *links          `json:"links,omitempty"`
+}
+
+// listDocument is the body of the list responses
+type listDocument struct {
+	Data  []models.Account `json:"data"`
+	Links *links           `json:"links"`
+}
+
+type links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// filters maps the filter[...] query parameters to the attribute they are applied to
+var filters = map[string]func(*models.AccountAttributes) string{
+	"bank_id":        func(a *models.AccountAttributes) string { return a.BankID },
+	"bank_id_code":   func(a *models.AccountAttributes) string { return a.BankIDCode },
+	"account_number": func(a *models.AccountAttributes) string { return a.AccountNumber },
+	"iban":           func(a *models.AccountAttributes) string { return a.Iban },
+	"customer_id":    func(a *models.AccountAttributes) string { return a.CustomerID },
+	"country":        func(a *models.AccountAttributes) string { return a.Country },
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var body document
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if failures := validate(body.Data); len(failures) > 0 {
+		writeError(w, http.StatusBadRequest, "validation failure list:\n"+strings.Join(failures, "\n"))
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.accounts[body.Data.ID]; ok {
+		writeError(w, http.StatusConflict, "Account cannot be created as it violates a duplicate constraint")
+		return
+	}
+
+	now := time.Now().UTC()
+	account := copyAccount(body.Data)
+	account.Version = 0
+	account.CreatedOn = &now
+	account.ModifiedOn = &now
+	s.store(account)
+
+	writeJSON(w, http.StatusCreated, document{Data: copyAccount(account), Links: selfLink(account.ID)})
+}
+
+func (s *Server) fetch(w http.ResponseWriter, id uuid.UUID) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "record "+id.String()+" does not exist")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, document{Data: copyAccount(account), Links: selfLink(id)})
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	pageNumber, err := queryInt(query, "page[number]", 0)
+	if err != nil || pageNumber < 0 {
+		writeError(w, http.StatusBadRequest, "invalid page number")
+		return
+	}
+	pageSize, err := queryInt(query, "page[size]", defaultPageSize)
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		writeError(w, http.StatusBadRequest, "invalid page size, it must be between 1 and "+strconv.Itoa(maxPageSize))
+		return
+	}
+
+	s.mutex.Lock()
+	var matching []models.Account
+	for _, id := range s.order {
+		account := s.accounts[id]
+		if matches(account, query) {
+			matching = append(matching, *copyAccount(account))
+		}
+	}
+	s.mutex.Unlock()
+
+	lastPage := 0
+	if len(matching) > 0 {
+		lastPage = (len(matching) - 1) / pageSize
+	}
+
+	page := []models.Account{}
+	if start := pageNumber * pageSize; start < len(matching) {
+		end := start + pageSize
+		if end > len(matching) {
+			end = len(matching)
+		}
+		page = matching[start:end]
+	}
+
+	pageLinks := &links{
+		Self:  pageLink(query, pageNumber, pageSize),
+		First: pageLink(query, 0, pageSize),
+		Last:  pageLink(query, lastPage, pageSize),
+	}
+	if pageNumber < lastPage {
+		pageLinks.Next = pageLink(query, pageNumber+1, pageSize)
+	}
+	if pageNumber > 0 {
+		pageLinks.Prev = pageLink(query, pageNumber-1, pageSize)
+	}
+
+	writeJSON(w, http.StatusOK, listDocument{Data: page, Links: pageLinks})
+}
+
+// update applies the attributes sent to the stored account.
+// The version sent must be the current one, the attributes not sent are left unchanged.
+func (s *Server) update(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Data == nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "record "+id.String()+" does not exist")
+		return
+	}
+
+	version, ok := body.Data["version"].(float64)
+	if !ok || int(version) != account.Version {
+		writeError(w, http.StatusConflict, "invalid version")
+		return
+	}
+
+	// the stored account and the changes are merged through their JSON representation
+	var merged map[string]interface{}
+	stored, _ := json.Marshal(account)
+	json.Unmarshal(stored, &merged)
+
+	if attributes, ok := body.Data["attributes"].(map[string]interface{}); ok {
+		mergedAttributes, _ := merged["attributes"].(map[string]interface{})
+		if mergedAttributes == nil {
+			mergedAttributes = make(map[string]interface{})
+		}
+		for key, value := range attributes {
+			if value != nil {
+				mergedAttributes[key] = value
+			}
+		}
+		merged["attributes"] = mergedAttributes
+	}
+
+	content, _ := json.Marshal(merged)
+	var updated models.Account
+	if err := json.Unmarshal(content, &updated); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid attributes: "+err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	updated.Version = account.Version + 1
+	updated.CreatedOn = account.CreatedOn
+	updated.ModifiedOn = &now
+	s.store(&updated)
+
+	writeJSON(w, http.StatusOK, document{Data: copyAccount(&updated), Links: selfLink(id)})
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, id uuid.UUID) {
+	version, err := strconv.Atoi(r.URL.Query().Get("version"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid version number")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "record "+id.String()+" does not exist")
+		return
+	}
+	if account.Version != version {
+		writeError(w, http.StatusConflict, "invalid version")
+		return
+	}
+
+	s.remove(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validate returns the validation failures of an account to create
+func validate(account *models.Account) []string {
+	if account == nil {
+		return []string{"data in body is required"}
+	}
+
+	var failures []string
+	if account.ID == uuid.Nil {
+		failures = append(failures, "id in body is required")
+	}
+	if account.OrganisationID == uuid.Nil {
+		failures = append(failures, "organisation_id in body is required")
+	}
+	if account.Type != "accounts" {
+		failures = append(failures, "type in body should be one of [accounts]")
+	}
+	if account.Attributes == nil {
+		failures = append(failures, "attributes in body is required")
+	} else if account.Attributes.Country == "" {
+		failures = append(failures, "country in body is required")
+	}
+	return failures
+}
+
+// matches reports whether the account passes every filter[...] of the query.
+// A filter holds a comma separated list of accepted values.
+func matches(account *models.Account, query url.Values) bool {
+	for key, values := range query {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		attribute, ok := filters[strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]")]
+		if !ok {
+			continue
+		}
+
+		value := ""
+		if account.Attributes != nil {
+			value = attribute(account.Attributes)
+		}
+
+		accepted := false
+		for _, list := range values {
+			for _, filter := range strings.Split(list, ",") {
+				if strings.TrimSpace(filter) == value {
+					accepted = true
+				}
+			}
+		}
+		if !accepted {
+			return false
+		}
+	}
+	return true
+}
+
+// pageLink returns the link to a page keeping the filters of the query
+func pageLink(query url.Values, pageNumber int, pageSize int) string {
+	values := url.Values{}
+	for key, value := range query {
+		if strings.HasPrefix(key, "filter[") {
+			values[key] = value
+		}
+	}
+	values.Set("page[number]", strconv.Itoa(pageNumber))
+	values.Set("page[size]", strconv.Itoa(pageSize))
+	return AccountsPath + "?" + values.Encode()
+}
+
+func selfLink(id uuid.UUID) *links {
+	return &links{Self: AccountsPath + "/" + id.String()}
+}
+
+func queryInt(query url.Values, key string, defaultValue int) (int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,45 @@
+// Command fakeapi serves the in memory account API, e.g. to try the command line without the docker-compose stack.
+// The --faults flag injects failures in front of the API, see fault.Parse for the syntax of the script.
+// With --client-id and --client-secret the API needs a bearer token issued by the token endpoint on /oauth2/token.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"form3-interview/fakeapi"
+	"form3-interview/fakeapi/fault"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	faults := flag.String("faults", "", "faults to inject, e.g. status:503*3,ratelimit:2s,pass*2")
+	clientID := flag.String("client-id", "", "OAuth2 client accepted by the token endpoint, the API is open when empty")
+	clientSecret := flag.String("client-secret", "", "secret of the OAuth2 client")
+	tokenTTL := flag.Duration("token-ttl", time.Hour, "validity of the tokens issued")
+	flag.Parse()
+
+	steps, err := fault.Parse(*faults)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var handler http.Handler = fakeapi.New()
+	if *clientID != "" {
+		tokens := fakeapi.NewTokenServer(*clientID, *clientSecret)
+		tokens.SetTTL(*tokenTTL)
+
+		mux := http.NewServeMux()
+		mux.Handle(fakeapi.TokenPath, tokens)
+		mux.Handle("/", tokens.Protect(handler))
+		handler = mux
+	}
+	if len(steps) > 0 {
+		handler = fault.New(handler, steps...)
+	}
+
+	log.Printf("fake account API listening on %v", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
@@ -0,0 +1,151 @@
+package fault_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"form3-interview/fakeapi"
+	"form3-interview/fakeapi/fault"
+	"form3-interview/httpclient"
+)
+
+// newFaultyClient returns a client calling the fake account API through a fault injector running the script
+func newFaultyClient(t *testing.T, policy httpclient.RetryPolicy, steps ...fault.Step) (*httpclient.Client, *fault.Injector) {
+	injector := fault.New(fakeapi.New(), steps...)
+	server := httptest.NewServer(injector)
+	t.Cleanup(server.Close)
+
+	client, err := httpclient.CreateHTTPClient(server.URL + fakeapi.AccountsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.HTTPClient = &http.Client{}
+	client.RetryPolicy = &policy
+	return client, injector
+}
+
+func TestRetryRecoversFromServerErrorBurst(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}
+	client, injector := newFaultyClient(t, policy, fault.Once(fault.Status(500)), fault.Times(2, fault.Status(503)))
+
+	_, err := client.Get(nil, nil)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed after the burst, got %v", err)
+	}
+	if injector.Requests() != 4 {
+		t.Errorf("Expected 4 attempts but got %v", injector.Requests())
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	client, injector := newFaultyClient(t, policy, fault.Always(fault.Status(502)))
+
+	_, err := client.Get(nil, nil)
+	if !httpclient.IsServerError(err) {
+		t.Errorf("Expected a server error, got %v", err)
+	}
+	if injector.Requests() != 3 {
+		t.Errorf("Expected 3 attempts but got %v", injector.Requests())
+	}
+}
+
+func TestRetryHonoursRetryAfterFromServer(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second}
+	client, injector := newFaultyClient(t, policy, fault.Once(fault.RateLimited(time.Second)))
+
+	start := time.Now()
+	_, err := client.Get(nil, nil)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed after the rate limit, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Retry-After not honoured, retried after %v", elapsed)
+	}
+	if injector.Requests() != 2 {
+		t.Errorf("Expected 2 attempts but got %v", injector.Requests())
+	}
+}
+
+func TestRetryStopsWhenRetryAfterExceedsMaxDelay(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	client, injector := newFaultyClient(t, policy, fault.Always(fault.Unavailable(10*time.Second)))
+
+	_, err := client.Get(nil, nil)
+	if !httpclient.IsServerError(err) {
+		t.Errorf("Expected a 503 error, got %v", err)
+	}
+	if injector.Requests() != 1 {
+		t.Errorf("Expected a single attempt but got %v", injector.Requests())
+	}
+}
+
+func TestGetRetriedAfterConnectionReset(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	client, injector := newFaultyClient(t, policy, fault.Once(fault.ConnectionReset()))
+
+	_, err := client.Get(nil, nil)
+	if err != nil {
+		t.Fatalf("Expected the request to succeed after the reset, got %v", err)
+	}
+	if injector.Requests() != 2 {
+		t.Errorf("Expected 2 attempts but got %v", injector.Requests())
+	}
+}
+
+func TestPostNotRetriedAfterConnectionReset(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	client, injector := newFaultyClient(t, policy, fault.Once(fault.ConnectionReset()).For(http.MethodPost))
+
+	_, err := client.Post(nil, []byte(`{"data":{}}`))
+	if err == nil {
+		t.Fatal("Expected the reset to be returned")
+	}
+	if injector.Requests() != 1 {
+		t.Errorf("A POST without idempotency key must not be retried, got %v attempts", injector.Requests())
+	}
+}
+
+func TestTruncatedBodyReturnsError(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	client, _ := newFaultyClient(t, policy, fault.Once(fault.TruncatedBody()))
+
+	_, err := client.Get(nil, nil)
+	if err == nil {
+		t.Error("Expected an error reading a truncated body")
+	}
+}
+
+func TestLatencyAbortedByContextDeadline(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	client, _ := newFaultyClient(t, policy, fault.Always(fault.Latency(2*time.Second)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetWithContext(ctx, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("The request was not aborted by the deadline, took %v", elapsed)
+	}
+}
+
+func TestSlowDripIsReadCompletely(t *testing.T) {
+	policy := httpclient.RetryPolicy{MaxAttempts: 1}
+	client, _ := newFaultyClient(t, policy, fault.Once(fault.SlowDrip(4, time.Millisecond)))
+
+	body, err := client.Get(nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if string(body) != "{\"data\":[],\"links\":{\"self\":\"/v1/organisation/accounts?page%5Bnumber%5D=0\\u0026page%5Bsize%5D=100\",\"first\":\"/v1/organisation/accounts?page%5Bnumber%5D=0\\u0026page%5Bsize%5D=100\",\"last\":\"/v1/organisation/accounts?page%5Bnumber%5D=0\\u0026page%5Bsize%5D=100\"}}\n" {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
@@ -0,0 +1,277 @@
+// Package fault injects failures in front of an http.Handler, e.g. the fake account API,
+// to test how a client copes with slow, failing or broken responses.
+//
+// The failures follow a script: every request consumes the next step of the script
+// and the requests received once the script is over reach the handler untouched.
+package fault
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fault handles a request in place of the wrapped handler.
+// A fault can still call next, e.g. to delay or to break the real response.
+type Fault func(w http.ResponseWriter, r *http.Request, next http.Handler)
+
+// Step applies a fault to a number of requests
+type Step struct {
+	fault  Fault
+	times  int
+	always bool
+	method string
+}
+
+// Once applies the fault to the next request only
+func Once(fault Fault) Step {
+	return Times(1, fault)
+}
+
+// Times applies the fault to the next n requests, none when n is not positive
+func Times(n int, fault Fault) Step {
+	return Step{fault: fault, times: n}
+}
+
+// Always applies the fault to every request, the steps after it are never reached
+func Always(fault Fault) Step {
+	return Step{fault: fault, always: true}
+}
+
+// Pass lets the next n requests reach the handler untouched
+func Pass(n int) Step {
+	return Times(n, nil)
+}
+
+// For restricts the step to the requests with the given method.
+// The other requests reach the handler without consuming the step.
+func (s Step) For(method string) Step {
+	s.method = method
+	return s
+}
+
+// Injector is an http.Handler applying the faults of its script before the wrapped handler.
+// It is safe for concurrent use.
+type Injector struct {
+	next http.Handler
+
+	mutex    sync.Mutex
+	steps    []Step
+	used     int
+	requests int
+}
+
+// New wraps the handler with an Injector running the script
+func New(next http.Handler, steps ...Step) *Injector {
+	return &Injector{next: next, steps: steps}
+}
+
+// Script replaces the steps of the injector and starts them from the first one
+func (i *Injector) Script(steps ...Step) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.steps = steps
+	i.used = 0
+	i.requests = 0
+}
+
+// Requests returns the number of requests received since the script started
+func (i *Injector) Requests() int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	return i.requests
+}
+
+// ServeHTTP applies the current step of the script to the request
+func (i *Injector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fault := i.nextFault(r)
+	if fault == nil {
+		i.next.ServeHTTP(w, r)
+		return
+	}
+	fault(w, r, i.next)
+}
+
+// nextFault consumes the step the request falls into and returns its fault
+func (i *Injector) nextFault(r *http.Request) Fault {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.requests = i.requests + 1
+
+	// the steps applying to no request are skipped
+	for len(i.steps) > 0 && !i.steps[0].always && i.steps[0].times <= 0 {
+		i.steps = i.steps[1:]
+	}
+	if len(i.steps) == 0 {
+		return nil
+	}
+
+	step := i.steps[0]
+	if step.method != "" && step.method != r.Method {
+		return nil
+	}
+
+	if step.always {
+		return step.fault
+	}
+
+	i.used = i.used + 1
+	if i.used >= step.times {
+		i.steps = i.steps[1:]
+		i.used = 0
+	}
+	return step.fault
+}
+
+// Latency delays the request before passing it to the handler.
+// The delay is cut short when the client goes away.
+func Latency(delay time.Duration) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timer.C:
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// Status answers with the status code and an error body like the one of the API
+func Status(statusCode int) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		writeError(w, statusCode)
+	}
+}
+
+// RateLimited answers 429 Too Many Requests with a Retry-After header in seconds
+func RateLimited(retryAfter time.Duration) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+		writeError(w, http.StatusTooManyRequests)
+	}
+}
+
+// Unavailable answers 503 Service Unavailable with a Retry-After header in seconds
+func Unavailable(retryAfter time.Duration) Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+		writeError(w, http.StatusServiceUnavailable)
+	}
+}
+
+// ConnectionReset closes the connection without answering.
+// The client sees a reset when the server supports hijacking, an empty response otherwise.
+func ConnectionReset() Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		conn, ok := hijack(w)
+		if !ok {
+			return
+		}
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			// discard the unsent data so that the close sends a RST
+			tcpConn.SetLinger(0)
+		}
+		conn.Close()
+	}
+}
+
+// TruncatedBody sends the headers of the real response but only half of its body, then closes the connection.
+// The client reads an unexpected EOF.
+func TruncatedBody() Fault {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		recorder := record(r, next)
+		body := recorder.Body.Bytes()
+
+		copyHeaders(w, recorder)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(recorder.Code)
+		w.Write(body[:len(body)/2])
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if conn, ok := hijack(w); ok {
+			conn.Close()
+		}
+	}
+}
+
+// SlowDrip sends the real response a chunk of bytes at a time, waiting interval between two chunks
+func SlowDrip(chunkSize int, interval time.Duration) Fault {
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		recorder := record(r, next)
+		body := recorder.Body.Bytes()
+
+		copyHeaders(w, recorder)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(recorder.Code)
+
+		flusher, _ := w.(http.Flusher)
+		for len(body) > 0 {
+			size := chunkSize
+			if size > len(body) {
+				size = len(body)
+			}
+			if _, err := w.Write(body[:size]); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			body = body[size:]
+
+			if len(body) > 0 {
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(interval):
+				}
+			}
+		}
+	}
+}
+
+// record runs the handler and keeps its response
+func record(r *http.Request, next http.Handler) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	next.ServeHTTP(recorder, r)
+	return recorder
+}
+
+func copyHeaders(w http.ResponseWriter, recorder *httptest.ResponseRecorder) {
+	for key, values := range recorder.Header() {
+		w.Header()[key] = values
+	}
+}
+
+func hijack(w http.ResponseWriter) (net.Conn, bool) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, false
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return nil, false
+	}
+	return conn, true
+}
+
+func writeError(w http.ResponseWriter, statusCode int) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"error_message": "injected fault: " + http.StatusText(statusCode)})
+}
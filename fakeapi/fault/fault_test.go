@@ -0,0 +1,191 @@
+package fault
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const body = `{"data":{"id":"ad27e265-9605-4b4b-a0e5-3003ea9cc4dc","type":"accounts"}}`
+
+func newTestServer(t *testing.T, steps ...Step) (*httptest.Server, *Injector) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.Write([]byte(body))
+	})
+	injector := New(handler, steps...)
+	server := httptest.NewServer(injector)
+	t.Cleanup(server.Close)
+	return server, injector
+}
+
+func get(t *testing.T, url string) (int, string, error) {
+	response, err := http.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer response.Body.Close()
+
+	content, err := ioutil.ReadAll(response.Body)
+	return response.StatusCode, string(content), err
+}
+
+func TestScriptStepsAreConsumedInOrder(t *testing.T) {
+	server, injector := newTestServer(t, Times(2, Status(503)), Once(Status(500)), Pass(1), Once(Status(502)))
+
+	expected := []int{503, 503, 500, 200, 502, 200, 200}
+	for i, statusCode := range expected {
+		got, _, err := get(t, server.URL)
+		if err != nil || got != statusCode {
+			t.Errorf("Request %d: expected %d but got %d %v", i, statusCode, got, err)
+		}
+	}
+
+	if injector.Requests() != len(expected) {
+		t.Errorf("Expected %d requests but got %d", len(expected), injector.Requests())
+	}
+}
+
+func TestAlwaysNeverEnds(t *testing.T) {
+	server, _ := newTestServer(t, Always(Status(504)), Once(Status(500)))
+
+	for i := 0; i < 5; i++ {
+		if got, _, _ := get(t, server.URL); got != 504 {
+			t.Errorf("Request %d: expected 504 but got %d", i, got)
+		}
+	}
+}
+
+func TestTimesWithoutRequests(t *testing.T) {
+	server, _ := newTestServer(t, Times(0, Status(500)), Times(-1, Status(502)), Pass(0), Once(Status(503)))
+
+	expected := []int{503, 200, 200}
+	for i, statusCode := range expected {
+		if got, _, _ := get(t, server.URL); got != statusCode {
+			t.Errorf("Request %d: expected %d but got %d", i, statusCode, got)
+		}
+	}
+}
+
+func TestStepForMethod(t *testing.T) {
+	server, _ := newTestServer(t, Once(Status(500)).For(http.MethodPost))
+
+	if got, _, _ := get(t, server.URL); got != 200 {
+		t.Errorf("GET should not consume a POST step, got %d", got)
+	}
+
+	response, err := http.Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil || response.StatusCode != 500 {
+		t.Fatalf("Expected the POST to fail, got %v %v", response, err)
+	}
+	response.Body.Close()
+}
+
+func TestScriptRestarts(t *testing.T) {
+	server, injector := newTestServer(t, Once(Status(500)))
+	get(t, server.URL)
+
+	injector.Script(Once(Status(429)))
+	if got, _, _ := get(t, server.URL); got != 429 {
+		t.Errorf("Expected 429 but got %d", got)
+	}
+	if injector.Requests() != 1 {
+		t.Errorf("Expected the counter to restart, got %d", injector.Requests())
+	}
+}
+
+func TestRateLimitedAndUnavailable(t *testing.T) {
+	server, _ := newTestServer(t, Once(RateLimited(2*time.Second)), Once(Unavailable(3*time.Second)))
+
+	for _, expected := range []struct {
+		statusCode int
+		retryAfter string
+	}{{429, "2"}, {503, "3"}} {
+		response, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != expected.statusCode || response.Header.Get("Retry-After") != expected.retryAfter {
+			t.Errorf("Expected %d with Retry-After %v but got %d %v", expected.statusCode, expected.retryAfter, response.StatusCode, response.Header.Get("Retry-After"))
+		}
+	}
+}
+
+func TestLatency(t *testing.T) {
+	server, _ := newTestServer(t, Once(Latency(100*time.Millisecond)))
+
+	start := time.Now()
+	got, content, err := get(t, server.URL)
+	if err != nil || got != 200 || content != body {
+		t.Errorf("Expected the real response, got %d %q %v", got, content, err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Response not delayed, took %v", elapsed)
+	}
+}
+
+func TestConnectionReset(t *testing.T) {
+	server, _ := newTestServer(t, Once(ConnectionReset()))
+
+	_, _, err := get(t, server.URL)
+	if err == nil {
+		t.Error("Expected a transport error")
+	}
+}
+
+func TestTruncatedBody(t *testing.T) {
+	server, _ := newTestServer(t, Once(TruncatedBody()))
+
+	got, content, err := get(t, server.URL)
+	if err == nil {
+		t.Errorf("Expected an error reading the body, got %d %q", got, content)
+	}
+	if len(content) != len(body)/2 {
+		t.Errorf("Expected half of the body, got %q", content)
+	}
+}
+
+func TestSlowDrip(t *testing.T) {
+	server, _ := newTestServer(t, Once(SlowDrip(len(body)/4+1, 20*time.Millisecond)))
+
+	start := time.Now()
+	got, content, err := get(t, server.URL)
+	if err != nil || got != 200 || content != body {
+		t.Errorf("Expected the real response, got %d %q %v", got, content, err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Response not dripped, took %v", elapsed)
+	}
+}
+
+func TestParse(t *testing.T) {
+	steps, err := Parse("status:503*3, ratelimit:2s, pass*2, latency:1s*, reset, truncate, drip:10ms, unavailable:1s")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	expectedTimes := []int{3, 1, 2, 0, 1, 1, 1, 1}
+	if len(steps) != len(expectedTimes) {
+		t.Fatalf("Expected %d steps but got %d", len(expectedTimes), len(steps))
+	}
+	for i, times := range expectedTimes {
+		if steps[i].times != times {
+			t.Errorf("Step %d: expected %d times but got %d", i, times, steps[i].times)
+		}
+	}
+	if steps[2].fault != nil {
+		t.Error("pass should not have a fault")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, script := range []string{"status:abc", "status:700", "latency", "latency:soon", "explode", "status:500*0", "status:500*x"} {
+		if _, err := Parse(script); err == nil {
+			t.Errorf("Expected an error parsing %q", script)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package fault
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dripChunkSize is the number of bytes sent at a time by the drip fault of a parsed script
+const dripChunkSize = 16
+
+// Parse reads a script written as a comma separated list of steps, e.g. "status:503*3,ratelimit:2s,pass*2,latency:1s*".
+//
+// A step is a fault followed by the number of requests it applies to, one when omitted.
+// A trailing * without a number applies the fault to every request. The faults are
+//
+//	pass                 the request reaches the handler untouched
+//	latency:<duration>   the request is delayed
+//	status:<code>        the request fails with the status code
+//	ratelimit:<duration> the request fails with 429 and a Retry-After header
+//	unavailable:<duration> the request fails with 503 and a Retry-After header
+//	reset                the connection is closed without a response
+//	truncate             half of the body is sent before closing the connection
+//	drip:<duration>      the body is sent 16 bytes at a time with a pause in between
+func Parse(script string) ([]Step, error) {
+	var steps []Step
+	for _, text := range strings.Split(script, ",") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		step, err := parseStep(text)
+		if err != nil {
+			return nil, fmt.Errorf("step %q: %w", text, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func parseStep(text string) (Step, error) {
+	times := 1
+	if star := strings.LastIndex(text, "*"); star >= 0 {
+		count := text[star+1:]
+		text = text[:star]
+		times = 0
+		if count != "" {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 1 {
+				return Step{}, fmt.Errorf("invalid number of requests %q", count)
+			}
+			times = n
+		}
+	}
+
+	name, argument := text, ""
+	if colon := strings.Index(text, ":"); colon >= 0 {
+		name, argument = text[:colon], text[colon+1:]
+	}
+
+	fault, err := parseFault(name, argument)
+	if err != nil {
+		return Step{}, err
+	}
+
+	if times == 0 {
+		return Always(fault), nil
+	}
+	return Times(times, fault), nil
+}
+
+func parseFault(name string, argument string) (Fault, error) {
+	switch name {
+	case "pass":
+		return nil, nil
+	case "reset":
+		return ConnectionReset(), nil
+	case "truncate":
+		return TruncatedBody(), nil
+	case "status":
+		statusCode, err := strconv.Atoi(argument)
+		if err != nil || statusCode < 100 || statusCode > 599 {
+			return nil, fmt.Errorf("invalid status code %q", argument)
+		}
+		return Status(statusCode), nil
+	}
+
+	duration, err := time.ParseDuration(argument)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q", argument)
+	}
+
+	switch name {
+	case "latency":
+		return Latency(duration), nil
+	case "ratelimit":
+		return RateLimited(duration), nil
+	case "unavailable":
+		return Unavailable(duration), nil
+	case "drip":
+		return SlowDrip(dripChunkSize, duration), nil
+	}
+
+	return nil, fmt.Errorf("unknown fault %q", name)
+}
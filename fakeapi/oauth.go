@@ -0,0 +1,151 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenPath is the path of the token endpoint of a TokenServer
+const TokenPath = "/oauth2/token"
+
+// TokenServer stands in for the OAuth2 authorization server of the staging environments.
+// It issues bearer tokens through the client credentials grant and protects a handler with them.
+// It is safe for concurrent use.
+type TokenServer struct {
+	clientID     string
+	clientSecret string
+
+	mutex  sync.Mutex
+	ttl    time.Duration
+	tokens map[string]time.Time
+	issued int
+}
+
+// tokenErrorBody is the body of a refused token request, as defined by RFC 6749
+type tokenErrorBody struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// NewTokenServer creates a TokenServer accepting the client credentials. Tokens are valid for an hour.
+func NewTokenServer(clientID string, clientSecret string) *TokenServer {
+	return &TokenServer{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		ttl:          time.Hour,
+		tokens:       make(map[string]time.Time),
+	}
+}
+
+// SetTTL sets how long the tokens issued from now are valid
+func (s *TokenServer) SetTTL(ttl time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ttl = ttl
+}
+
+// Issued returns the number of tokens issued
+func (s *TokenServer) Issued() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.issued
+}
+
+// RevokeAll invalidates every token issued, the requests using them get 401 Unauthorized
+func (s *TokenServer) RevokeAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tokens = make(map[string]time.Time)
+}
+
+// ServeHTTP implements the token endpoint
+func (s *TokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type", "grant type "+grantType+" is not supported")
+		return
+	}
+
+	clientID, clientSecret, ok := clientCredentials(r)
+	if !ok || clientID != s.clientID || clientSecret != s.clientSecret {
+		w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+		writeTokenError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+
+	token := uuid.New().String()
+
+	s.mutex.Lock()
+	ttl := s.ttl
+	s.tokens[token] = time.Now().Add(ttl)
+	s.issued = s.issued + 1
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(ttl / time.Second),
+	})
+}
+
+// Protect returns a handler refusing the requests without a valid bearer token with 401 Unauthorized
+func (s *TokenServer) Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mutex.Lock()
+		expiry, ok := s.tokens[token]
+		s.mutex.Unlock()
+
+		if !ok || time.Now().After(expiry) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, "invalid or expired token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientCredentials reads the client credentials from the basic authentication or from the form
+func clientCredentials(r *http.Request) (string, string, bool) {
+	if clientID, clientSecret, ok := r.BasicAuth(); ok {
+		// RFC 6749 form encodes the credentials before the basic authentication
+		id, err := url.QueryUnescape(clientID)
+		if err != nil {
+			return "", "", false
+		}
+		secret, err := url.QueryUnescape(clientSecret)
+		if err != nil {
+			return "", "", false
+		}
+		return id, secret, true
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	return clientID, r.PostForm.Get("client_secret"), clientID != ""
+}
+
+func writeTokenError(w http.ResponseWriter, statusCode int, code string, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(tokenErrorBody{Error: code, ErrorDescription: description})
+}
@@ -0,0 +1,114 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func requestToken(t *testing.T, server *TokenServer, form url.Values, clientID string, clientSecret string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, TokenPath, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		request.SetBasicAuth(clientID, clientSecret)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestTokenServerIssuesTokens(t *testing.T) {
+	server := NewTokenServer("client", "s3cret")
+
+	recorder := requestToken(t, server, url.Values{"grant_type": {"client_credentials"}}, "client", "s3cret")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 but got %v %v", recorder.Code, recorder.Body.String())
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &token)
+	if token.AccessToken == "" || token.TokenType != "Bearer" || token.ExpiresIn != 3600 {
+		t.Errorf("Unexpected token %+v", token)
+	}
+	if server.Issued() != 1 {
+		t.Errorf("Expected 1 token issued but got %v", server.Issued())
+	}
+
+	protected := server.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for bearer, expected := range map[string]int{token.AccessToken: http.StatusNoContent, "forged": http.StatusUnauthorized, "": http.StatusUnauthorized} {
+		request := httptest.NewRequest(http.MethodGet, AccountsPath, nil)
+		if bearer != "" {
+			request.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		recorder := httptest.NewRecorder()
+		protected.ServeHTTP(recorder, request)
+		if recorder.Code != expected {
+			t.Errorf("Token %q: expected %v but got %v", bearer, expected, recorder.Code)
+		}
+	}
+
+	server.RevokeAll()
+	request := httptest.NewRequest(http.MethodGet, AccountsPath, nil)
+	request.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	recorder = httptest.NewRecorder()
+	protected.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a revoked token to be refused, got %v", recorder.Code)
+	}
+}
+
+func TestTokenServerExpiry(t *testing.T) {
+	server := NewTokenServer("client", "s3cret")
+	server.SetTTL(-time.Second)
+
+	recorder := requestToken(t, server, url.Values{"grant_type": {"client_credentials"}}, "client", "s3cret")
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &token)
+
+	protected := server.Protect(http.NotFoundHandler())
+	request := httptest.NewRequest(http.MethodGet, AccountsPath, nil)
+	request.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	recorder = httptest.NewRecorder()
+	protected.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an expired token to be refused, got %v", recorder.Code)
+	}
+}
+
+func TestTokenServerRefusesRequests(t *testing.T) {
+	server := NewTokenServer("client", "s3cret")
+
+	for name, test := range map[string]struct {
+		form         url.Values
+		clientID     string
+		clientSecret string
+		statusCode   int
+		code         string
+	}{
+		"wrong secret":  {url.Values{"grant_type": {"client_credentials"}}, "client", "wrong", 401, "invalid_client"},
+		"no client":     {url.Values{"grant_type": {"client_credentials"}}, "", "", 401, "invalid_client"},
+		"wrong grant":   {url.Values{"grant_type": {"password"}}, "client", "s3cret", 400, "unsupported_grant_type"},
+		"form clientID": {url.Values{"grant_type": {"client_credentials"}, "client_id": {"client"}, "client_secret": {"s3cret"}}, "", "", 200, ""},
+	} {
+		recorder := requestToken(t, server, test.form, test.clientID, test.clientSecret)
+
+		var body tokenErrorBody
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		if recorder.Code != test.statusCode || body.Error != test.code {
+			t.Errorf("%v: expected %v %v but got %v %v", name, test.statusCode, test.code, recorder.Code, body.Error)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package fakeapi provides an in memory implementation of the account endpoints of the Form3 API.
+// It is meant to run the account client against a hermetic server, e.g. through httptest.NewServer(fakeapi.New()).
+package fakeapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+// AccountsPath is the path of the account endpoints
+const AccountsPath = "/v1/organisation/accounts"
+
+// maxPageSize is the largest page returned by the list endpoint
+const maxPageSize = 1000
+
+// defaultPageSize is used when the list request has no page[size]
+const defaultPageSize = 100
+
+// Server is an http.Handler storing the accounts in memory.
+// It is safe for concurrent use.
+type Server struct {
+	mutex    sync.Mutex
+	accounts map[uuid.UUID]*models.Account
+	// order keeps the accounts in the order of creation, used by the list endpoint
+	order []uuid.UUID
+}
+
+// errorBody is the body returned by the API when a request fails
+type errorBody struct {
+	ErrorMessage string `json:"error_message"`
+	ErrorCode    string `json:"error_code,omitempty"`
+}
+
+// New creates an empty Server
+func New() *Server {
+	return &Server{accounts: make(map[uuid.UUID]*models.Account)}
+}
+
+// Add stores accounts as if they had been created through the API
+func (s *Server) Add(accounts ...models.Account) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, account := range accounts {
+		s.store(copyAccount(&account))
+	}
+}
+
+// Accounts returns a copy of the accounts stored, in the order of creation
+func (s *Server) Accounts() []models.Account {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	accounts := make([]models.Account, 0, len(s.order))
+	for _, id := range s.order {
+		accounts = append(accounts, *copyAccount(s.accounts[id]))
+	}
+	return accounts
+}
+
+// Reset removes every account
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.accounts = make(map[uuid.UUID]*models.Account)
+	s.order = nil
+}
+
+// ServeHTTP routes the request to the account endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Request-Id", uuid.New().String())
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	if path == AccountsPath {
+		switch r.Method {
+		case http.MethodPost:
+			s.create(w, r)
+		case http.MethodGet:
+			s.list(w, r)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+		return
+	}
+
+	if !strings.HasPrefix(path, AccountsPath+"/") {
+		writeError(w, http.StatusNotFound, "resource not found")
+		return
+	}
+
+	id, err := uuid.Parse(strings.TrimPrefix(path, AccountsPath+"/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id is not a valid uuid")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.fetch(w, id)
+	case http.MethodPatch:
+		s.update(w, r, id)
+	case http.MethodDelete:
+		s.delete(w, r, id)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+// store saves the account, keeping the order of creation. The mutex must be held.
+func (s *Server) store(account *models.Account) {
+	if _, ok := s.accounts[account.ID]; !ok {
+		s.order = append(s.order, account.ID)
+	}
+	s.accounts[account.ID] = account
+}
+
+// remove deletes the account. The mutex must be held.
+func (s *Server) remove(id uuid.UUID) {
+	delete(s.accounts, id)
+	for i, orderedID := range s.order {
+		if orderedID == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, errorBody{ErrorMessage: message})
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// copyAccount returns a deep copy of the account so that the stored accounts can not be changed by the callers
+func copyAccount(account *models.Account) *models.Account {
+	body, _ := json.Marshal(account)
+	var copied models.Account
+	json.Unmarshal(body, &copied)
+	return &copied
+}
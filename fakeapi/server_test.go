@@ -0,0 +1,261 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"form3-interview/models"
+
+	"github.com/google/uuid"
+)
+
+func newAccount(country string, bankID string) models.Account {
+	return models.Account{
+		Type:           "accounts",
+		ID:             uuid.New(),
+		OrganisationID: uuid.New(),
+		Attributes:     &models.AccountAttributes{Country: country, BankID: bankID},
+	}
+}
+
+func send(t *testing.T, server *Server, method string, target string, body interface{}) *httptest.ResponseRecorder {
+	var content bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&content).Encode(body)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(method, target, &content))
+	return recorder
+}
+
+func errorMessage(recorder *httptest.ResponseRecorder) string {
+	var body errorBody
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	return body.ErrorMessage
+}
+
+func TestCreateAndFetch(t *testing.T) {
+	server := New()
+	account := newAccount("GB", "400300")
+	account.Version = 3
+
+	recorder := send(t, server, http.MethodPost, AccountsPath, document{Data: &account})
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 but got %v: %v", recorder.Code, recorder.Body)
+	}
+
+	recorder = send(t, server, http.MethodGet, AccountsPath+"/"+account.ID.String(), nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 but got %v", recorder.Code)
+	}
+
+	var fetched document
+	json.Unmarshal(recorder.Body.Bytes(), &fetched)
+	if fetched.Data.ID != account.ID || fetched.Data.Version != 0 || fetched.Data.Attributes.BankID != "400300" {
+		t.Errorf("Unexpected account fetched %+v", fetched.Data)
+	}
+	if fetched.Links.Self != AccountsPath+"/"+account.ID.String() {
+		t.Errorf("Unexpected self link %v", fetched.Links.Self)
+	}
+	if fetched.Data.CreatedOn == nil || fetched.Data.ModifiedOn == nil || !fetched.Data.CreatedOn.Equal(*fetched.Data.ModifiedOn) {
+		t.Errorf("Expected the creation time on a new account, got %v and %v", fetched.Data.CreatedOn, fetched.Data.ModifiedOn)
+	}
+	if recorder.Header().Get("X-Request-Id") == "" {
+		t.Error("Response without request ID")
+	}
+}
+
+func TestCreateDuplicate(t *testing.T) {
+	server := New()
+	account := newAccount("GB", "400300")
+	server.Add(account)
+
+	recorder := send(t, server, http.MethodPost, AccountsPath, document{Data: &account})
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("Expected 409 but got %v", recorder.Code)
+	}
+	if !strings.Contains(errorMessage(recorder), "duplicate constraint") {
+		t.Errorf("Unexpected error message %q", errorMessage(recorder))
+	}
+}
+
+func TestCreateInvalid(t *testing.T) {
+	server := New()
+
+	recorder := send(t, server, http.MethodPost, AccountsPath, document{Data: &models.Account{Type: "account"}})
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 but got %v", recorder.Code)
+	}
+
+	expected := "validation failure list:\n" +
+		"id in body is required\n" +
+		"organisation_id in body is required\n" +
+		"type in body should be one of [accounts]\n" +
+		"attributes in body is required"
+	if errorMessage(recorder) != expected {
+		t.Errorf("Unexpected error message %q", errorMessage(recorder))
+	}
+
+	recorder = send(t, server, http.MethodPost, AccountsPath, "not an account")
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 but got %v", recorder.Code)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	server := New()
+
+	recorder := send(t, server, http.MethodGet, AccountsPath+"/"+uuid.New().String(), nil)
+	if recorder.Code != http.StatusNotFound || !strings.Contains(errorMessage(recorder), "does not exist") {
+		t.Errorf("Expected 404 but got %v %q", recorder.Code, errorMessage(recorder))
+	}
+
+	recorder = send(t, server, http.MethodGet, AccountsPath+"/not-a-uuid", nil)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 but got %v", recorder.Code)
+	}
+
+	recorder = send(t, server, http.MethodGet, "/v1/organisation/unknown", nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 but got %v", recorder.Code)
+	}
+
+	recorder = send(t, server, http.MethodPut, AccountsPath, nil)
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") == "" {
+		t.Errorf("Expected 405 but got %v", recorder.Code)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	server := New()
+	for i := 0; i < 5; i++ {
+		server.Add(newAccount("GB", fmt.Sprint(i)))
+	}
+
+	recorder := send(t, server, http.MethodGet, AccountsPath+"?page[number]=1&page[size]=2", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 but got %v", recorder.Code)
+	}
+
+	var page listDocument
+	json.Unmarshal(recorder.Body.Bytes(), &page)
+	if len(page.Data) != 2 || page.Data[0].Attributes.BankID != "2" || page.Data[1].Attributes.BankID != "3" {
+		t.Errorf("Unexpected page %+v", page.Data)
+	}
+
+	expected := links{
+		Self:  AccountsPath + "?page%5Bnumber%5D=1&page%5Bsize%5D=2",
+		First: AccountsPath + "?page%5Bnumber%5D=0&page%5Bsize%5D=2",
+		Next:  AccountsPath + "?page%5Bnumber%5D=2&page%5Bsize%5D=2",
+		Prev:  AccountsPath + "?page%5Bnumber%5D=0&page%5Bsize%5D=2",
+		Last:  AccountsPath + "?page%5Bnumber%5D=2&page%5Bsize%5D=2",
+	}
+	if *page.Links != expected {
+		t.Errorf("Unexpected links\ngot      %+v\nexpected %+v", *page.Links, expected)
+	}
+
+	recorder = send(t, server, http.MethodGet, AccountsPath+"?page[number]=9", nil)
+	var emptyPage listDocument
+	json.Unmarshal(recorder.Body.Bytes(), &emptyPage)
+	if recorder.Code != http.StatusOK || len(emptyPage.Data) != 0 || emptyPage.Links.Next != "" {
+		t.Errorf("Expected an empty page but got %v %+v", recorder.Code, emptyPage)
+	}
+
+	recorder = send(t, server, http.MethodGet, AccountsPath+"?page[size]=0", nil)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 but got %v", recorder.Code)
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	server := New()
+	server.Add(newAccount("GB", "1"), newAccount("FR", "2"), newAccount("DE", "3"), newAccount("GB", "4"))
+
+	recorder := send(t, server, http.MethodGet, AccountsPath+"?filter[country]=GB,DE&filter[bank_id]=1,3,4&page[size]=2", nil)
+
+	var page listDocument
+	json.Unmarshal(recorder.Body.Bytes(), &page)
+	if len(page.Data) != 2 || page.Data[0].Attributes.BankID != "1" || page.Data[1].Attributes.BankID != "3" {
+		t.Errorf("Unexpected accounts %+v", page.Data)
+	}
+	if !strings.Contains(page.Links.Next, "filter%5Bcountry%5D=GB%2CDE") {
+		t.Errorf("Filters not kept in the next link %v", page.Links.Next)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	server := New()
+	account := newAccount("GB", "400300")
+	account.Attributes.CustomerID = "ref1"
+	server.Add(account)
+
+	changes := models.Account{ID: account.ID, Version: 0, Attributes: &models.AccountAttributes{CustomerID: "ref2"}}
+	recorder := send(t, server, http.MethodPatch, AccountsPath+"/"+account.ID.String(), document{Data: &changes})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200 but got %v: %v", recorder.Code, recorder.Body)
+	}
+
+	stored := server.Accounts()[0]
+	if stored.Version != 1 || stored.Attributes.CustomerID != "ref2" || stored.Attributes.BankID != "400300" {
+		t.Errorf("Unexpected account after update %+v %+v", stored, stored.Attributes)
+	}
+	if stored.ModifiedOn == nil {
+		t.Errorf("Expected the modification time after update")
+	}
+
+	recorder = send(t, server, http.MethodPatch, AccountsPath+"/"+account.ID.String(), document{Data: &changes})
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for an old version but got %v", recorder.Code)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	server := New()
+	account := newAccount("GB", "400300")
+	server.Add(account)
+	target := AccountsPath + "/" + account.ID.String()
+
+	recorder := send(t, server, http.MethodDelete, target+"?version=1", nil)
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a wrong version but got %v", recorder.Code)
+	}
+
+	recorder = send(t, server, http.MethodDelete, target, nil)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without version but got %v", recorder.Code)
+	}
+
+	recorder = send(t, server, http.MethodDelete, target+"?version=0", nil)
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 but got %v", recorder.Code)
+	}
+
+	recorder = send(t, server, http.MethodDelete, target+"?version=0", nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete but got %v", recorder.Code)
+	}
+	if len(server.Accounts()) != 0 {
+		t.Errorf("Account not deleted")
+	}
+}
+
+func TestAccountsReturnsCopies(t *testing.T) {
+	server := New()
+	server.Add(newAccount("GB", "400300"))
+
+	server.Accounts()[0].Attributes.BankID = "changed"
+	if server.Accounts()[0].Attributes.BankID != "400300" {
+		t.Error("Stored account changed through the copy")
+	}
+
+	server.Reset()
+	if len(server.Accounts()) != 0 {
+		t.Error("Accounts not removed by Reset")
+	}
+}
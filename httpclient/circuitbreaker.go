@@ -0,0 +1,377 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, wrapped in a CircuitOpenError, when a request is refused by an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit of a host
+type CircuitState int
+
+const (
+	// StateClosed lets every request through and records their outcome
+	StateClosed CircuitState = iota
+	// StateOpen refuses every request until the cooldown is over
+	StateOpen
+	// StateHalfOpen lets a few probe requests through to find out whether the host recovered
+	StateHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown(" + strconv.Itoa(int(s)) + ")"
+}
+
+// CircuitOpenError is returned when the circuit of the host is open.
+// errors.Is(err, ErrCircuitOpen) reports true for it.
+type CircuitOpenError struct {
+	Host string
+	// RetryAfter is how long before the circuit lets a probe request through
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return ErrCircuitOpen.Error() + " for host " + e.Host + ", retry after " + e.RetryAfter.String()
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) true for a CircuitOpenError
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreaker stops sending requests to a host once too many of them failed.
+//
+// Every host has its own circuit. A closed circuit opens when, over the rolling Window,
+// at least MinimumRequests were sent and the rate of failures reaches FailureRateThreshold.
+// An open circuit fails every request with a CircuitOpenError until Cooldown is over,
+// it then lets HalfOpenRequests probes through: the circuit closes when all of them
+// succeed and opens again on the first failure. The requests still in flight when the circuit
+// changed state are not counted, whatever their outcome.
+//
+// The zero value uses the defaults documented on each field.
+// A CircuitBreaker is safe for concurrent use and is meant to be shared by the clients calling the same hosts.
+type CircuitBreaker struct {
+	// FailureRateThreshold is the rate of failed requests, between 0 and 1, opening the circuit. Defaults to 0.5.
+	FailureRateThreshold float64
+
+	// MinimumRequests is the number of requests in the window needed before the failure rate is considered. Defaults to 10.
+	MinimumRequests int
+
+	// Window is the duration over which the failure rate is computed. Defaults to one minute.
+	Window time.Duration
+
+	// Buckets is the number of slices the window is split in, the oldest one is dropped as time passes. Defaults to 10.
+	Buckets int
+
+	// Cooldown is how long the circuit stays open before letting probe requests through. Defaults to 30 seconds.
+	Cooldown time.Duration
+
+	// HalfOpenRequests is the number of successful probes needed to close the circuit. Defaults to 1.
+	HalfOpenRequests int
+
+	// IsFailure decides whether the outcome of a request counts as a failure.
+	// By default transport errors and the 429 and 5xx responses are failures.
+	IsFailure func(response *http.Response, err error) bool
+
+	// OnStateChange is called every time the circuit of a host changes state, e.g. to feed monitoring
+	OnStateChange func(host string, from CircuitState, to CircuitState)
+
+	mutex    sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+// circuit is the state of a single host
+type circuit struct {
+	state    CircuitState
+	openedAt time.Time
+	buckets  []bucket
+	probes   int
+	passed   int
+
+	// generation changes with the state, the requests allowed in a previous state are not counted
+	generation int
+}
+
+// admission is given by allow to a request let through, its outcome is recorded with it
+type admission struct {
+	generation int
+}
+
+// bucket counts the requests of a slice of the rolling window
+type bucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+// stateChange is a transition reported to OnStateChange once the lock is released
+type stateChange struct {
+	host string
+	from CircuitState
+	to   CircuitState
+}
+
+// State returns the current state of the circuit of the host
+func (b *CircuitBreaker) State(host string) CircuitState {
+	b.mutex.Lock()
+	c, ok := b.circuits[host]
+	if !ok {
+		b.mutex.Unlock()
+		return StateClosed
+	}
+	change := b.refresh(host, c)
+	state := c.state
+	b.mutex.Unlock()
+
+	b.notify(change)
+	return state
+}
+
+// Reset closes every circuit and forgets the requests recorded
+func (b *CircuitBreaker) Reset() {
+	b.mutex.Lock()
+	var changes []stateChange
+	for host, c := range b.circuits {
+		if c.state != StateClosed {
+			changes = append(changes, stateChange{host: host, from: c.state, to: StateClosed})
+		}
+	}
+	b.circuits = nil
+	b.mutex.Unlock()
+
+	for _, change := range changes {
+		b.notify(&change)
+	}
+}
+
+// allow reports whether a request to the host can be sent.
+// In half-open state it reserves one of the probes, the outcome must then be recorded.
+func (b *CircuitBreaker) allow(host string) (admission, error) {
+	b.mutex.Lock()
+	c := b.circuit(host)
+	change := b.refresh(host, c)
+	allowed := admission{generation: c.generation}
+
+	var err error
+	switch c.state {
+	case StateOpen:
+		err = &CircuitOpenError{Host: host, RetryAfter: c.openedAt.Add(b.cooldown()).Sub(b.clock())}
+	case StateHalfOpen:
+		if c.probes >= b.halfOpenRequests() {
+			err = &CircuitOpenError{Host: host}
+		} else {
+			c.probes = c.probes + 1
+		}
+	}
+	b.mutex.Unlock()
+
+	b.notify(change)
+	return allowed, err
+}
+
+// release gives back the probe reserved by allow for a request that has not been sent
+func (b *CircuitBreaker) release(host string, allowed admission) {
+	b.mutex.Lock()
+	if c := b.circuit(host); c.state == StateHalfOpen && c.generation == allowed.generation && c.probes > 0 {
+		c.probes = c.probes - 1
+	}
+	b.mutex.Unlock()
+}
+
+// record stores the outcome of a request allowed to the host.
+// A request allowed before the circuit changed state is not counted: in half-open state only the probes are.
+func (b *CircuitBreaker) record(ctx context.Context, host string, allowed admission, response *http.Response, err error) {
+	// a request cancelled or out of time for the caller says nothing about the host,
+	// unlike the timeout of the http.Client
+	if ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		b.release(host, allowed)
+		return
+	}
+
+	failed := b.isFailure(response, err)
+
+	b.mutex.Lock()
+	c := b.circuit(host)
+	if c.generation != allowed.generation {
+		b.mutex.Unlock()
+		return
+	}
+	var change *stateChange
+
+	switch c.state {
+	case StateHalfOpen:
+		if failed {
+			change = b.setState(host, c, StateOpen)
+		} else {
+			c.passed = c.passed + 1
+			if c.passed >= b.halfOpenRequests() {
+				change = b.setState(host, c, StateClosed)
+			}
+		}
+	case StateClosed:
+		current := b.currentBucket(c)
+		current.requests = current.requests + 1
+		if failed {
+			current.failures = current.failures + 1
+		}
+
+		requests, failures := b.totals(c)
+		if failed && requests >= b.minimumRequests() && float64(failures)/float64(requests) >= b.failureRateThreshold() {
+			change = b.setState(host, c, StateOpen)
+		}
+	}
+	b.mutex.Unlock()
+
+	b.notify(change)
+}
+
+// refresh moves an open circuit to half-open once the cooldown is over
+func (b *CircuitBreaker) refresh(host string, c *circuit) *stateChange {
+	if c.state == StateOpen && !b.clock().Before(c.openedAt.Add(b.cooldown())) {
+		return b.setState(host, c, StateHalfOpen)
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) setState(host string, c *circuit, state CircuitState) *stateChange {
+	change := &stateChange{host: host, from: c.state, to: state}
+
+	c.state = state
+	c.generation = c.generation + 1
+	c.probes = 0
+	c.passed = 0
+	switch state {
+	case StateOpen:
+		c.openedAt = b.clock()
+	case StateClosed:
+		// a closed circuit starts again from an empty window
+		c.buckets = nil
+	}
+
+	return change
+}
+
+func (b *CircuitBreaker) notify(change *stateChange) {
+	if change != nil && b.OnStateChange != nil {
+		b.OnStateChange(change.host, change.from, change.to)
+	}
+}
+
+func (b *CircuitBreaker) circuit(host string) *circuit {
+	if b.circuits == nil {
+		b.circuits = make(map[string]*circuit)
+	}
+	c, ok := b.circuits[host]
+	if !ok {
+		c = &circuit{}
+		b.circuits[host] = c
+	}
+	return c
+}
+
+// currentBucket returns the bucket of the current time, dropping the buckets out of the window
+func (b *CircuitBreaker) currentBucket(c *circuit) *bucket {
+	if len(c.buckets) != b.buckets() {
+		c.buckets = make([]bucket, b.buckets())
+	}
+
+	width := b.window() / time.Duration(len(c.buckets))
+	if width <= 0 {
+		width = 1
+	}
+	now := b.clock()
+	start := now.Truncate(width)
+	current := &c.buckets[int(start.UnixNano()/int64(width))%len(c.buckets)]
+	if !current.start.Equal(start) {
+		*current = bucket{start: start}
+	}
+	return current
+}
+
+// totals sums the requests and failures of the buckets still in the window
+func (b *CircuitBreaker) totals(c *circuit) (int, int) {
+	oldest := b.clock().Add(-b.window())
+
+	var requests, failures int
+	for _, bucket := range c.buckets {
+		if bucket.start.After(oldest) {
+			requests = requests + bucket.requests
+			failures = failures + bucket.failures
+		}
+	}
+	return requests, failures
+}
+
+func (b *CircuitBreaker) isFailure(response *http.Response, err error) bool {
+	if b.IsFailure != nil {
+		return b.IsFailure(response, err)
+	}
+	if err != nil {
+		return true
+	}
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError
+}
+
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+func (b *CircuitBreaker) failureRateThreshold() float64 {
+	if b.FailureRateThreshold <= 0 {
+		return 0.5
+	}
+	return b.FailureRateThreshold
+}
+
+func (b *CircuitBreaker) minimumRequests() int {
+	if b.MinimumRequests <= 0 {
+		return 10
+	}
+	return b.MinimumRequests
+}
+
+func (b *CircuitBreaker) window() time.Duration {
+	if b.Window <= 0 {
+		return time.Minute
+	}
+	return b.Window
+}
+
+func (b *CircuitBreaker) buckets() int {
+	if b.Buckets <= 0 {
+		return 10
+	}
+	return b.Buckets
+}
+
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown <= 0 {
+		return 30 * time.Second
+	}
+	return b.Cooldown
+}
+
+func (b *CircuitBreaker) halfOpenRequests() int {
+	if b.HalfOpenRequests <= 0 {
+		return 1
+	}
+	return b.HalfOpenRequests
+}
@@ -0,0 +1,397 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testHost = "api.test"
+
+// fakeClock is a clock moved forward by the tests
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// stateRecorder keeps the state changes reported by a breaker
+type stateRecorder struct {
+	mutex   sync.Mutex
+	changes []string
+}
+
+func (r *stateRecorder) record(host string, from CircuitState, to CircuitState) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.changes = append(r.changes, host+" "+from.String()+"->"+to.String())
+}
+
+func (r *stateRecorder) get() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.changes...)
+}
+
+func newTestBreaker() (*CircuitBreaker, *fakeClock, *stateRecorder) {
+	clock := &fakeClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	recorder := &stateRecorder{}
+	breaker := &CircuitBreaker{
+		FailureRateThreshold: 0.5,
+		MinimumRequests:      4,
+		Window:               10 * time.Second,
+		Buckets:              10,
+		Cooldown:             5 * time.Second,
+		OnStateChange:        recorder.record,
+		now:                  clock.Now,
+	}
+	return breaker, clock, recorder
+}
+
+func status(statusCode int) *http.Response {
+	return &http.Response{StatusCode: statusCode}
+}
+
+func sendThroughBreaker(breaker *CircuitBreaker, statusCode int) error {
+	allowed, err := breaker.allow(testHost)
+	if err != nil {
+		return err
+	}
+	breaker.record(context.Background(), testHost, allowed, status(statusCode), nil)
+	return nil
+}
+
+func TestCircuitOpensOnFailureRate(t *testing.T) {
+	breaker, _, recorder := newTestBreaker()
+
+	for _, statusCode := range []int{200, 500, 200} {
+		sendThroughBreaker(breaker, statusCode)
+	}
+	if breaker.State(testHost) != StateClosed {
+		t.Fatal("The circuit must stay closed below the minimum number of requests")
+	}
+
+	sendThroughBreaker(breaker, 503)
+	if breaker.State(testHost) != StateOpen {
+		t.Fatalf("Expected the circuit to open at 50%% of failures, got %v", breaker.State(testHost))
+	}
+
+	_, err := breaker.allow(testHost)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen but got %v", err)
+	}
+	var openError *CircuitOpenError
+	if !errors.As(err, &openError) || openError.Host != testHost || openError.RetryAfter != 5*time.Second {
+		t.Errorf("Unexpected error %#v", err)
+	}
+
+	if changes := recorder.get(); len(changes) != 1 || changes[0] != testHost+" closed->open" {
+		t.Errorf("Unexpected state changes %v", changes)
+	}
+}
+
+func TestCircuitSuccessesDoNotOpen(t *testing.T) {
+	breaker, _, _ := newTestBreaker()
+
+	for i := 0; i < 20; i++ {
+		sendThroughBreaker(breaker, 200)
+	}
+	sendThroughBreaker(breaker, 500)
+	sendThroughBreaker(breaker, 404)
+
+	if breaker.State(testHost) != StateClosed {
+		t.Errorf("Expected the circuit to stay closed, got %v", breaker.State(testHost))
+	}
+}
+
+func TestCircuitWindowDropsOldRequests(t *testing.T) {
+	breaker, clock, _ := newTestBreaker()
+
+	for i := 0; i < 3; i++ {
+		sendThroughBreaker(breaker, 500)
+	}
+	clock.Advance(11 * time.Second)
+
+	// the old failures are out of the window, a single failure is below the minimum
+	sendThroughBreaker(breaker, 500)
+	if breaker.State(testHost) != StateClosed {
+		t.Errorf("Expected the circuit to stay closed, got %v", breaker.State(testHost))
+	}
+}
+
+func TestCircuitHalfOpenClosesAfterProbe(t *testing.T) {
+	breaker, clock, recorder := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		sendThroughBreaker(breaker, 500)
+	}
+
+	clock.Advance(5 * time.Second)
+	if breaker.State(testHost) != StateHalfOpen {
+		t.Fatalf("Expected the circuit to be half-open after the cooldown, got %v", breaker.State(testHost))
+	}
+
+	// a single probe is let through
+	probe, err := breaker.allow(testHost)
+	if err != nil {
+		t.Fatalf("Expected the probe to be allowed, got %v", err)
+	}
+	if _, err := breaker.allow(testHost); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a second concurrent probe to be refused, got %v", err)
+	}
+
+	breaker.record(context.Background(), testHost, probe, status(200), nil)
+	if breaker.State(testHost) != StateClosed {
+		t.Errorf("Expected the circuit to close after a successful probe, got %v", breaker.State(testHost))
+	}
+
+	expected := []string{testHost + " closed->open", testHost + " open->half-open", testHost + " half-open->closed"}
+	changes := recorder.get()
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Expected %v but got %v", expected, changes)
+		}
+	}
+}
+
+func TestCircuitHalfOpenReopensOnFailure(t *testing.T) {
+	breaker, clock, _ := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		sendThroughBreaker(breaker, 500)
+	}
+	clock.Advance(5 * time.Second)
+
+	if err := sendThroughBreaker(breaker, 502); err != nil {
+		t.Fatalf("Expected the probe to be allowed, got %v", err)
+	}
+	if breaker.State(testHost) != StateOpen {
+		t.Errorf("Expected the circuit to open again, got %v", breaker.State(testHost))
+	}
+}
+
+func TestCircuitHalfOpenCountsOnlyTheProbe(t *testing.T) {
+	breaker, clock, _ := newTestBreaker()
+
+	// a slow request is let through before the circuit opens
+	slow, err := breaker.allow(testHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		sendThroughBreaker(breaker, 500)
+	}
+	clock.Advance(5 * time.Second)
+	probe, err := breaker.allow(testHost)
+	if err != nil {
+		t.Fatalf("Expected the probe to be allowed, got %v", err)
+	}
+
+	// the slow request completes while the probe is in flight
+	breaker.record(context.Background(), testHost, slow, status(200), nil)
+	if breaker.State(testHost) != StateHalfOpen {
+		t.Fatalf("Expected the request allowed before the circuit opened to be ignored, got %v", breaker.State(testHost))
+	}
+	breaker.release(testHost, slow)
+	if _, err := breaker.allow(testHost); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the probe to stay reserved, got %v", err)
+	}
+
+	breaker.record(context.Background(), testHost, probe, status(500), nil)
+	if breaker.State(testHost) != StateOpen {
+		t.Errorf("Expected the failed probe to open the circuit, got %v", breaker.State(testHost))
+	}
+}
+
+func TestCircuitHostsAreIndependent(t *testing.T) {
+	breaker, _, _ := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		sendThroughBreaker(breaker, 500)
+	}
+
+	if _, err := breaker.allow("other.test"); err != nil {
+		t.Errorf("A failing host must not open the circuit of another one, got %v", err)
+	}
+}
+
+func TestCircuitReset(t *testing.T) {
+	breaker, _, recorder := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		sendThroughBreaker(breaker, 500)
+	}
+
+	breaker.Reset()
+	if breaker.State(testHost) != StateClosed {
+		t.Errorf("Expected the circuit to be closed after a reset, got %v", breaker.State(testHost))
+	}
+	if changes := recorder.get(); changes[len(changes)-1] != testHost+" open->closed" {
+		t.Errorf("Unexpected state changes %v", changes)
+	}
+}
+
+func TestClientFailsFastWhenCircuitOpen(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 11, BaseDelay: time.Millisecond}
+	client, server := newScriptedClient(t, policy, errorStatus(503), errorStatus(503), errorStatus(503))
+	client.CircuitBreaker = &CircuitBreaker{MinimumRequests: 3, Cooldown: time.Minute}
+
+	_, err := client.Get(nil, nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected the retries to stop on the open circuit, got %v", err)
+	}
+	if server.Requests() != 3 {
+		t.Errorf("Expected 3 attempts before the circuit opened but got %v", server.Requests())
+	}
+
+	_, err = client.Get(nil, nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the next call to fail fast, got %v", err)
+	}
+	if server.Requests() != 3 {
+		t.Errorf("No request should reach the server while the circuit is open, got %v", server.Requests())
+	}
+}
+
+func TestClientRecoversThroughHalfOpenCircuit(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	policy := RetryPolicy{MaxAttempts: 1}
+	client, server := newScriptedClient(t, policy, errorStatus(500), errorStatus(500))
+	client.CircuitBreaker = &CircuitBreaker{MinimumRequests: 2, Cooldown: time.Second, now: clock.Now}
+
+	client.Get(nil, nil)
+	client.Get(nil, nil)
+	if _, err := client.Get(nil, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected the circuit to be open, got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	if _, err := client.Get(nil, nil); err != nil {
+		t.Fatalf("Expected the probe to reach the recovered server, got %v", err)
+	}
+	if server.Requests() != 3 {
+		t.Errorf("Expected 3 requests but got %v", server.Requests())
+	}
+}
+
+// countingAuthorizer counts the attempts it authorizes and fails them with err
+type countingAuthorizer struct {
+	calls int
+	err   error
+}
+
+func (a *countingAuthorizer) Authorize(request *http.Request) error {
+	a.calls = a.calls + 1
+	return a.err
+}
+
+func TestClientChecksTheCircuitFirst(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 1}
+	client, _ := newScriptedClient(t, policy, errorStatus(500), errorStatus(500))
+	client.CircuitBreaker = &CircuitBreaker{MinimumRequests: 2, Cooldown: time.Minute}
+	client.Get(nil, nil)
+	client.Get(nil, nil)
+
+	authorizer := &countingAuthorizer{}
+	client.Authorizer = authorizer
+	client.RateLimiter = NewRateLimiter(Rate{Requests: 1, Period: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetWithContext(ctx, nil, nil); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected call %v to fail fast on the open circuit, got %v", i, err)
+		}
+	}
+	if authorizer.calls != 0 {
+		t.Errorf("Expected no token to be requested while the circuit is open, got %v calls", authorizer.calls)
+	}
+}
+
+func TestClientReleasesTheProbeNotSent(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	policy := RetryPolicy{MaxAttempts: 1}
+	client, server := newScriptedClient(t, policy, errorStatus(500))
+	client.CircuitBreaker = &CircuitBreaker{MinimumRequests: 1, Cooldown: time.Second, now: clock.Now}
+	client.Get(nil, nil)
+	clock.Advance(time.Second)
+
+	authorizer := &countingAuthorizer{err: errors.New("no token")}
+	client.Authorizer = authorizer
+	if _, err := client.Get(nil, nil); err != authorizer.err {
+		t.Fatalf("Expected the authorizer error, got %v", err)
+	}
+
+	authorizer.err = nil
+	if _, err := client.Get(nil, nil); err != nil {
+		t.Fatalf("Expected the probe to be sent once authorized, got %v", err)
+	}
+	if server.Requests() != 2 {
+		t.Errorf("Expected 2 requests but got %v", server.Requests())
+	}
+}
+
+func TestCircuitIgnoresTheDeadlineOfTheCaller(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}
+	policy := RetryPolicy{MaxAttempts: 1}
+	client, _ := newScriptedClient(t, policy, slow, slow)
+	client.CircuitBreaker = &CircuitBreaker{MinimumRequests: 1, Cooldown: time.Minute}
+	baseURL, _ := url.Parse(client.baseURL)
+	host := baseURL.Host
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetWithContext(ctx, nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if client.CircuitBreaker.State(host) != StateClosed {
+		t.Errorf("Expected the deadline of the caller not to count as a failure, got %v", client.CircuitBreaker.State(host))
+	}
+
+	// the timeout of the http.Client is a slow host
+	client.HTTPClient = &http.Client{Timeout: 50 * time.Millisecond}
+	if _, err := client.Get(nil, nil); err == nil {
+		t.Fatal("Expected the http.Client to time out")
+	}
+	if client.CircuitBreaker.State(host) != StateOpen {
+		t.Errorf("Expected the timeout of the http.Client to count as a failure, got %v", client.CircuitBreaker.State(host))
+	}
+}
+
+func TestCircuitBreakerConcurrentUse(t *testing.T) {
+	breaker := &CircuitBreaker{MinimumRequests: 5, Cooldown: time.Millisecond}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if allowed, err := breaker.allow(testHost); err == nil {
+					breaker.record(context.Background(), testHost, allowed, status(200+300*(j%2)), nil)
+				}
+				breaker.State(testHost)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
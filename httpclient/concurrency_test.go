@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestClientIsSafeForConcurrentUse(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			res.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprintf(res, "%v %v", req.URL.Path, req.URL.RawQuery)
+	}))
+	defer func() { testServer.Close() }()
+
+	client, err := CreateHTTPClient(testServer.URL + "/v1/organisation/accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.CircuitBreaker = &CircuitBreaker{}
+	client.RateLimiter = NewRateLimiter(Rate{Requests: 10000})
+	client.Metrics = NewPrometheusMetrics("test")
+	client.Tracer = NewSpanRecorder()
+	client.Use(RequestID())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := context.Background()
+			page := strconv.Itoa(i)
+
+			body, err := client.GetWithContext(ctx, nil, map[string]string{"page[number]": page})
+			if expected := "/v1/organisation/accounts page%5Bnumber%5D=" + page; err != nil || string(body) != expected {
+				t.Errorf("Get %v: expected %q but got %q, %v", i, expected, body, err)
+			}
+
+			body, err = client.Send(ctx, Request{Method: http.MethodGet, Path: "/account-" + page})
+			if expected := "/v1/organisation/accounts/account-" + page + " "; err != nil || string(body) != expected {
+				t.Errorf("Send %v: expected %q but got %q, %v", i, expected, body, err)
+			}
+
+			if err := client.DeleteWithContext(ctx, nil, map[string]string{"version": page}); err != nil {
+				t.Errorf("Delete %v: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if client.baseURL != testServer.URL+"/v1/organisation/accounts" {
+		t.Errorf("The base URL must not change, got %v", client.baseURL)
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	for _, test := range []struct {
+		baseURL  string
+		path     string
+		query    map[string]string
+		expected string
+	}{
+		{"http://api.test", "", nil, "http://api.test"},
+		{"http://api.test", "/v1/organisation/accounts", nil, "http://api.test/v1/organisation/accounts"},
+		{"http://api.test/", "/v1/organisation/accounts/", nil, "http://api.test/v1/organisation/accounts/"},
+		{"http://api.test/v1/organisation/accounts", "ad27e265", map[string]string{"version": "0"}, "http://api.test/v1/organisation/accounts/ad27e265?version=0"},
+		{"http://api.test/v1?tenant=form3", "", map[string]string{"page[size]": "10"}, "http://api.test/v1?page%5Bsize%5D=10&tenant=form3"},
+		{"http://api.test", "/a b", nil, "http://api.test/a%20b"},
+	} {
+		client := &Client{baseURL: test.baseURL}
+		requestURL, err := client.requestURL(test.path, test.query)
+		if err != nil || requestURL != test.expected {
+			t.Errorf("%v + %v: expected %v but got %v, %v", test.baseURL, test.path, test.expected, requestURL, err)
+		}
+	}
+}
+
+func TestClientsShareTransport(t *testing.T) {
+	first, _ := CreateHTTPClient("http://api.test")
+	second, _ := CreateHTTPClient("http://other.test")
+
+	if first.HTTPClient != second.HTTPClient || first.HTTPClient.(*http.Client).Transport != sharedTransport {
+		t.Errorf("Expected the clients to share the pooled transport")
+	}
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// maximum number of bytes read from an error response body
+const maxErrorBodySize = 64 * 1024
+
+// APIError is returned when the API answers with a non successful status code.
+// It carries the error body returned by Form3 and the request that caused it.
+type APIError struct {
+	// HTTP status code of the response, e.g. 404
+	StatusCode int `json:"-"`
+
+	// HTTP status of the response, e.g. "404 Not Found"
+	Status string `json:"-"`
+
+	// Human readable description of the error returned by the API
+	ErrorMessage string `json:"error_message"`
+
+	// Code identifying the error returned by the API
+	ErrorCode string `json:"error_code"`
+
+	// ID of the request as returned in the X-Request-Id header
+	RequestID string `json:"-"`
+
+	// Method and URL of the request that failed
+	Method string `json:"-"`
+	URL    string `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	if e.ErrorMessage != "" {
+		return e.Status + ": " + e.ErrorMessage
+	}
+	return e.Status
+}
+
+// newAPIError builds an APIError from a non successful response.
+// The body is decoded when it contains a Form3 error and ignored otherwise.
+func newAPIError(request *http.Request, response *http.Response) *APIError {
+	apiError := &APIError{
+		StatusCode: response.StatusCode,
+		Status:     response.Status,
+		RequestID:  response.Header.Get("X-Request-Id"),
+		Method:     request.Method,
+		URL:        request.URL.String(),
+	}
+
+	if response.Body != nil {
+		body, err := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		if err == nil {
+			json.Unmarshal(body, apiError)
+		}
+	}
+
+	return apiError
+}
+
+// IsBadRequest reports whether err is an APIError with a 400 status code
+func IsBadRequest(err error) bool {
+	return hasStatusCode(err, http.StatusBadRequest)
+}
+
+// IsNotFound reports whether err is an APIError with a 404 status code
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an APIError with a 409 status code
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+// IsRateLimited reports whether err is an APIError with a 429 status code
+func IsRateLimited(err error) bool {
+	return hasStatusCode(err, http.StatusTooManyRequests)
+}
+
+// IsServerError reports whether err is an APIError with a 5xx status code
+func IsServerError(err error) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.StatusCode >= 500
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && apiError.StatusCode == statusCode
+}
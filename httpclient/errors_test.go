@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorDecodesErrorBody(t *testing.T) {
+	body := `{"error_message":"Account cannot be created as it violates a duplicate constraint","error_code":"ACC-409"}`
+
+	goClient := &MockClient{
+		MockedDo: func(req *http.Request) (*http.Response, error) {
+			header := make(http.Header)
+			header.Set("X-Request-Id", "c0ffee")
+			return &http.Response{
+				StatusCode: http.StatusConflict,
+				Status:     "409 Conflict",
+				Header:     header,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+			}, nil
+		},
+	}
+
+	client := Client{
+		HTTPClient: goClient,
+		baseURL:    testServerUrl,
+	}
+
+	_, err := client.Post(nil, []byte("hello world"))
+
+	var apiError *APIError
+	if !errors.As(err, &apiError) {
+		t.Fatalf("request returning an error that is not an APIError: got %v", err)
+	}
+	if apiError.StatusCode != http.StatusConflict {
+		t.Errorf("APIError has wrong status code: got %v expected %v", apiError.StatusCode, http.StatusConflict)
+	}
+	if apiError.ErrorMessage != "Account cannot be created as it violates a duplicate constraint" {
+		t.Errorf("APIError has wrong error message: got %v", apiError.ErrorMessage)
+	}
+	if apiError.ErrorCode != "ACC-409" {
+		t.Errorf("APIError has wrong error code: got %v expected %v", apiError.ErrorCode, "ACC-409")
+	}
+	if apiError.RequestID != "c0ffee" {
+		t.Errorf("APIError has wrong request ID: got %v expected %v", apiError.RequestID, "c0ffee")
+	}
+	if apiError.Method != "POST" || apiError.URL != testServerUrl {
+		t.Errorf("APIError has wrong request: got %v %v expected %v %v", apiError.Method, apiError.URL, "POST", testServerUrl)
+	}
+
+	expectedMessage := "409 Conflict: Account cannot be created as it violates a duplicate constraint"
+	if err.Error() != expectedMessage {
+		t.Errorf("APIError has wrong message: got %v expected %v", err.Error(), expectedMessage)
+	}
+}
+
+func TestAPIErrorWithoutErrorBody(t *testing.T) {
+	client, _ := getMockedClientResponse("<html>not found</html>", http.StatusNotFound, "404 Not Found")
+
+	_, err := client.Get(nil, nil)
+	if err.Error() != "404 Not Found" {
+		t.Errorf("APIError has wrong message: got %v expected %v", err.Error(), "404 Not Found")
+	}
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		check      func(error) bool
+	}{
+		{http.StatusBadRequest, IsBadRequest},
+		{http.StatusNotFound, IsNotFound},
+		{http.StatusConflict, IsConflict},
+		{http.StatusTooManyRequests, IsRateLimited},
+		{http.StatusBadGateway, IsServerError},
+	}
+
+	for _, test := range tests {
+		// helpers must see through wrapped errors
+		err := fmt.Errorf("calling the API: %w", &APIError{StatusCode: test.statusCode})
+		if !test.check(err) {
+			t.Errorf("helper not matching status code %v", test.statusCode)
+		}
+		if test.check(&APIError{StatusCode: http.StatusOK}) {
+			t.Errorf("helper for status code %v matching a different status code", test.statusCode)
+		}
+		if test.check(errors.New("plain error")) {
+			t.Errorf("helper for status code %v matching a non APIError", test.statusCode)
+		}
+	}
+}
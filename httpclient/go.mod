@@ -1,3 +1,3 @@
 module form3.com/httpclient
 
-go 1.15
+go 1.21
@@ -2,157 +2,276 @@ package httpclient
 
 import (
 	"bytes"
-	"errors"
+	"context"
+	"io"
 	"io/ioutil"
-	"math"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
 const requestTimeout = 30
+
+// maxRetries is the number of retries done by DefaultRetryPolicy
 const maxRetries = 10
 
 type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// Client sends requests to an API, with retries and the optional features configured through its fields.
+// The base url is never modified: every request builds its own url from the path and the query parameters.
+// A Client is safe for concurrent use by multiple goroutines once configured,
+// its fields and middlewares must not be changed while requests are in flight.
 type Client struct {
 	baseURL    string
 	HTTPClient HttpClient
+	// RetryPolicy overrides DefaultRetryPolicy when set
+	RetryPolicy *RetryPolicy
+	// CircuitBreaker, when set, fails the requests fast while the host keeps failing
+	CircuitBreaker *CircuitBreaker
+	// RateLimiter, when set, delays the requests exceeding the rate of the host
+	RateLimiter *RateLimiter
+	// Authorizer, when set, adds the credentials of the caller to every attempt of the requests
+	Authorizer Authorizer
+	// Signer, when set, signs every attempt of the requests. It replaces the Authorization header set by the Authorizer.
+	Signer Signer
+	// RequestLogger, when set, logs every attempt of the requests
+	RequestLogger *RequestLogger
+	// Metrics, when set, measures the requests
+	Metrics Metrics
+	// Tracer, when set, traces every attempt of the requests and propagates the spans to the API
+	Tracer Tracer
+
+	// middlewares wrap HTTPClient, see Use
+	middlewares []Middleware
+}
+
+// Request is a request sent with Client.Send
+type Request struct {
+	Method string
+	// Path is added to the path of the base url, e.g. "/" + accountID
+	Path string
+	// Query parameters added to the url
+	Query   map[string]string
+	Headers map[string]string
+	// Body of the request, none when nil
+	Body []byte
+}
+
+// sharedTransport pools the connections of every Client created without an HTTPClient
+var sharedTransport = newTransport()
+
+// defaultHTTPClient is the HTTPClient of the clients created by CreateHTTPClient
+var defaultHTTPClient = &http.Client{
+	Timeout:   time.Duration(requestTimeout) * time.Second,
+	Transport: sharedTransport,
+}
+
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// the requests of a client go to a few hosts, keep enough connections open for concurrent calls
+	transport.MaxIdleConnsPerHost = 32
+	return transport
 }
 
-//CreateHTTPClient creates an HTTPClient to perform an Http request
+// CreateHTTPClient creates an HTTPClient to perform an Http request.
+// The clients created share a pool of connections.
 func CreateHTTPClient(requestURL string) (*Client, error) {
 	_, err := url.ParseRequestURI(requestURL)
 	if err != nil {
 		return nil, err
 	}
 	return &Client{
-		HTTPClient: &http.Client{
-			Timeout: time.Duration(requestTimeout) * time.Second,
-		},
-		baseURL: requestURL,
+		HTTPClient: defaultHTTPClient,
+		baseURL:    requestURL,
 	}, nil
 }
 
-//Get send an http get request using the url passed through
-//it also accept a list of headers option to add to the request
-func (c *Client) Get(headers map[string]string, queryParams map[string]string) ([]byte, error) {
-
-	// add parameters to the url
-	v := url.Values{}
-	for key, value := range queryParams {
-		v.Add(key, value)
-	}
-	uri, err := url.Parse(c.baseURL)
+// Send sends the request and returns the body of the response.
+// A response with a status outside of 2xx is returned as an *APIError.
+func (c *Client) Send(ctx context.Context, request Request) ([]byte, error) {
+	httpRequest, response, err := c.send(ctx, request)
 	if err != nil {
 		return nil, err
 	}
-	uri.RawQuery = v.Encode()
-	c.baseURL = uri.String()
+	defer closeBody(response)
 
-	// create a new get request
-	request, err := http.NewRequest("GET", c.baseURL, nil)
-	if err != nil {
-		return nil, err
+	// if response is an error (not a 2xx)
+	if response.StatusCode > 299 {
+		return nil, newAPIError(httpRequest, response)
 	}
 
-	// add headers to the request
-	for key, value := range headers {
-		request.Header.Add(key, value)
-	}
+	// read the body as an array of bytes
+	return ioutil.ReadAll(response.Body)
+}
 
-	response, err := c.sendRequestWithRetry(request)
+// Stream sends the request and passes the body of the response to read, without loading it in memory.
+// The body is closed once read returns. A response with a status outside of 2xx is returned as an *APIError
+// and read is not called.
+func (c *Client) Stream(ctx context.Context, request Request, read func(body io.Reader) error) error {
+	httpRequest, response, err := c.send(ctx, request)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer closeBody(response)
 
-	// if response is an error (not a 200)
+	// if response is an error (not a 2xx)
 	if response.StatusCode > 299 {
-		return nil, errors.New(response.Status)
+		return newAPIError(httpRequest, response)
 	}
-	// read the body as an array of bytes
-	responseBody, err := ioutil.ReadAll(response.Body)
-	return responseBody, err
+
+	return read(response.Body)
+}
+
+// Get send an http get request using the url passed through
+// it also accept a list of headers option to add to the request
+func (c *Client) Get(headers map[string]string, queryParams map[string]string) ([]byte, error) {
+	return c.GetWithContext(context.Background(), headers, queryParams)
 }
 
+// GetWithContext works like Get but binds the request to ctx.
+// Cancelling ctx aborts the request, including any retry in progress
+func (c *Client) GetWithContext(ctx context.Context, headers map[string]string, queryParams map[string]string) ([]byte, error) {
+	return c.Send(ctx, Request{Method: http.MethodGet, Query: queryParams, Headers: headers})
+}
+
+// Post send an http post request with the body passed through
+// it also accept a list of headers option to add to the request
 func (c *Client) Post(headers map[string]string, body []byte) ([]byte, error) {
+	return c.PostWithContext(context.Background(), headers, body)
+}
 
-	uri, err := url.Parse(c.baseURL)
-	if err != nil {
-		return nil, err
-	}
-	c.baseURL = uri.String()
+// PostWithContext works like Post but binds the request to ctx.
+// Cancelling ctx aborts the request, including any retry in progress
+func (c *Client) PostWithContext(ctx context.Context, headers map[string]string, body []byte) ([]byte, error) {
+	return c.Send(ctx, Request{Method: http.MethodPost, Headers: headers, Body: body})
+}
+
+// Patch send an http patch request with the body passed through
+// it also accept a list of headers option to add to the request
+func (c *Client) Patch(headers map[string]string, body []byte) ([]byte, error) {
+	return c.PatchWithContext(context.Background(), headers, body)
+}
+
+// PatchWithContext works like Patch but binds the request to ctx.
+// Cancelling ctx aborts the request, including any retry in progress
+func (c *Client) PatchWithContext(ctx context.Context, headers map[string]string, body []byte) ([]byte, error) {
+	return c.Send(ctx, Request{Method: http.MethodPatch, Headers: headers, Body: body})
+}
+
+// Delete send an http delete request using the url passed through
+// it also accept a list of headers option to add to the request
+func (c *Client) Delete(headers map[string]string, queryParams map[string]string) error {
+	return c.DeleteWithContext(context.Background(), headers, queryParams)
+}
 
-	// create a new post request
-	request, err := http.NewRequest("POST", c.baseURL, bytes.NewBuffer(body))
+// DeleteWithContext works like Delete but binds the request to ctx.
+// Cancelling ctx aborts the request, including any retry in progress
+func (c *Client) DeleteWithContext(ctx context.Context, headers map[string]string, queryParams map[string]string) error {
+	return c.SendNoContent(ctx, Request{Method: http.MethodDelete, Query: queryParams, Headers: headers})
+}
+
+// SendNoContent sends a request answered without a body, like a delete.
+// A response with a status other than 204 is returned as an *APIError.
+func (c *Client) SendNoContent(ctx context.Context, request Request) error {
+	httpRequest, response, err := c.send(ctx, request)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer closeBody(response)
 
-	// add headers to the request
-	for key, value := range headers {
-		request.Header.Add(key, value)
+	// if response is an error (not a 204)
+	if response.StatusCode != http.StatusNoContent {
+		return newAPIError(httpRequest, response)
 	}
 
-	response, err := c.sendRequestWithRetry(request)
+	return nil
+}
+
+// send builds the request and sends it, the caller closes the body of the response
+func (c *Client) send(ctx context.Context, request Request) (*http.Request, *http.Response, error) {
+	requestURL, err := c.requestURL(request.Path, request.Query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// if response is an error (not a 200)
-	if response.StatusCode > 299 {
-		return nil, errors.New(response.Status)
+	var body io.Reader
+	if request.Body != nil {
+		body = bytes.NewReader(request.Body)
 	}
 
-	// read the body as an array of bytes
-	responseBody, err := ioutil.ReadAll(response.Body)
-	return responseBody, err
-}
+	httpRequest, err := http.NewRequestWithContext(ctx, request.Method, requestURL, body)
+	if err != nil {
+		return nil, nil, err
+	}
 
-func (c *Client) Delete(headers map[string]string, queryParams map[string]string) error {
+	// add headers to the request
+	addHeaders(httpRequest, request.Headers)
 
-	// add parameters to the url
-	v := url.Values{}
-	for key, value := range queryParams {
-		v.Add(key, value)
+	response, err := c.sendRequestWithRetry(ctx, httpRequest)
+	if err != nil {
+		return nil, nil, err
 	}
+	return httpRequest, response, nil
+}
+
+// requestURL returns the base url followed by the path and the query parameters
+func (c *Client) requestURL(path string, queryParams map[string]string) (string, error) {
 	uri, err := url.Parse(c.baseURL)
 	if err != nil {
-		return err
+		return "", err
 	}
-	uri.RawQuery = v.Encode()
-	c.baseURL = uri.String()
 
-	// create a new delete request
-	request, err := http.NewRequest("DELETE", c.baseURL, nil)
-	if err != nil {
-		return err
+	if path != "" {
+		uri.Path = strings.TrimSuffix(uri.Path, "/") + "/" + strings.TrimPrefix(path, "/")
+		uri.RawPath = ""
 	}
 
-	// add headers to the request
-	for key, value := range headers {
-		request.Header.Add(key, value)
+	if len(queryParams) > 0 {
+		query := uri.Query()
+		for key, value := range queryParams {
+			query.Set(key, value)
+		}
+		uri.RawQuery = query.Encode()
 	}
 
-	response, err := c.sendRequestWithRetry(request)
-	if err != nil {
-		return err
+	return uri.String(), nil
+}
+
+// closeBody releases the connection of the response
+func closeBody(response *http.Response) {
+	if response.Body != nil {
+		response.Body.Close()
 	}
+}
 
-	// if response is an error (not a 204)
-	if response.StatusCode != 204 {
-		return errors.New(response.Status)
+// addHeaders adds the headers to the request.
+// The Host header is set on the request itself, the http package ignores it in the header map.
+func addHeaders(request *http.Request, headers map[string]string) {
+	for key, value := range headers {
+		if http.CanonicalHeaderKey(key) == "Host" {
+			if value != "" {
+				request.Host = value
+			}
+			continue
+		}
+		request.Header.Add(key, value)
 	}
+}
 
-	return nil
+func (c *Client) sendRequestWithRetry(ctx context.Context, request *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := c.sendAttempts(ctx, request)
+	c.observeRequest(ctx, request, response, err, time.Since(start))
+	return response, err
 }
 
-func (c *Client) sendRequestWithRetry(request *http.Request) (*http.Response, error) {
+// sendAttempts sends the request until it succeeds or the retry policy gives up
+func (c *Client) sendAttempts(ctx context.Context, request *http.Request) (*http.Response, error) {
 
-	retryCount := 0
-	retry := true
+	policy := c.retryPolicy()
 	var response *http.Response
 	var err error
 	var data []byte
@@ -169,40 +288,160 @@ func (c *Client) sendRequestWithRetry(request *http.Request) (*http.Response, er
 		}
 	}
 
-	// if we need to retry and we have not exceed the retry limit
-	for retry && retryCount <= maxRetries {
+	// the request is always sent at least once
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
 
-		// if we need to retry then wait
-		if retryCount > 0 {
-			// sleeping time is calculated has 1.5^number of retry multiplied 500
-			sleepingTime := math.Pow(1.5, float64(retryCount)) * float64(500)
-			time.Sleep(time.Duration(sleepingTime))
+	// the signer is the innermost step of the chain, the signature covers the headers set by the middlewares
+	var signErr error
+	send := DoerFunc(func(request *http.Request) (*http.Response, error) {
+		// every attempt is signed again, the signature covers the date of the attempt
+		if c.Signer != nil {
+			if signErr = c.Signer.Sign(request, data); signErr != nil {
+				return nil, signErr
+			}
 		}
+		return c.HTTPClient.Do(request)
+	})
+
+	// the middlewares run for every attempt
+	doer := Chain(send, c.middlewares...)
+
+	var delay time.Duration
+	tokenRefreshed := false
+	for attempt := 1; ; attempt++ {
 
 		// populate the body
-		request.Body = ioutil.NopCloser(bytes.NewReader(data))
-		// send the request
-		response, err = c.HTTPClient.Do(request)
-		if err != nil {
+		if data != nil {
+			request.Body = ioutil.NopCloser(bytes.NewReader(data))
+		}
+
+		// an open circuit fails fast, without waiting for the rate limiter nor getting a token
+		var allowed admission
+		if c.CircuitBreaker != nil {
+			if allowed, err = c.CircuitBreaker.allow(request.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := c.prepareAttempt(ctx, request); err != nil {
+			// the attempt allowed by the circuit is not sent
+			if c.CircuitBreaker != nil {
+				c.CircuitBreaker.release(request.URL.Host, allowed)
+			}
 			return nil, err
 		}
 
-		// based on status code, do we need a retry?
-		if response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusInternalServerError || response.StatusCode == http.StatusBadGateway || response.StatusCode == http.StatusServiceUnavailable || response.StatusCode == http.StatusGatewayTimeout {
-			retryCount = retryCount + 1
-			retry = true
-		} else {
-			retry = false
+		// send the request
+		span := c.startAttemptSpan(ctx, request, attempt)
+		start := time.Now()
+		signErr = nil
+		response, err = doer.Do(request)
+		entry := attemptLog{request: request, requestBody: data, response: response, err: err, attempt: attempt, latency: time.Since(start)}
+
+		// an attempt that can not be signed is not sent
+		if signErr != nil {
+			if c.CircuitBreaker != nil {
+				c.CircuitBreaker.release(request.URL.Host, allowed)
+			}
+			endAttemptSpan(span, entry)
+			return nil, signErr
 		}
-	}
 
-	if request.Body != nil {
-		// close the original body, we don't need it anymore
-		if err := request.Body.Close(); err != nil {
+		if c.CircuitBreaker != nil {
+			c.CircuitBreaker.record(ctx, request.URL.Host, allowed, response, err)
+		}
+		if c.RateLimiter != nil {
+			c.RateLimiter.Update(request.URL.Host, request.Method, response)
+		}
+
+		var responseBody []byte
+		if c.RequestLogger != nil {
+			responseBody = c.RequestLogger.captureBody(response)
+		}
+
+		// a refused token is replaced once, the new attempt does not count in the retries
+		if err == nil && response.StatusCode == http.StatusUnauthorized && !tokenRefreshed {
+			if invalidator, ok := c.Authorizer.(TokenInvalidator); ok {
+				entry.retryReason = "token refused"
+				c.logAttempt(ctx, entry, responseBody)
+				endAttemptSpan(span, entry)
+				c.observeRetry(ctx, request, entry.retryReason)
+
+				invalidator.InvalidateToken()
+				tokenRefreshed = true
+				response.Body.Close()
+				attempt--
+				continue
+			}
+		}
+
+		// based on the outcome and the policy, do we need a retry?
+		retry := false
+		if attempt < maxAttempts {
+			if reason := policy.retryReason(request, response, err); reason != "" {
+				delay, retry = policy.backoff(attempt, delay, response)
+				if retry {
+					entry.retryReason = reason
+					entry.retryDelay = delay
+				}
+			}
+		}
+		c.logAttempt(ctx, entry, responseBody)
+		endAttemptSpan(span, entry)
+		if !retry {
+			break
+		}
+
+		// the previous response is discarded, release its connection
+		if response != nil && response.Body != nil {
+			response.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		// the timer and the context can expire together, never retry a cancelled request
+		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
+		c.observeRetry(ctx, request, entry.retryReason)
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
 	return response, nil
 
 }
+
+// prepareAttempt waits for the rate limiter, then authorizes the attempt
+func (c *Client) prepareAttempt(ctx context.Context, request *http.Request) error {
+	// wait for the budget of the host, every attempt counts
+	if c.RateLimiter != nil {
+		if err := c.RateLimiter.Wait(ctx, request.URL.Host, request.Method); err != nil {
+			return err
+		}
+	}
+
+	// the token can expire between attempts
+	if c.Authorizer != nil {
+		return c.Authorizer.Authorize(request)
+	}
+	return nil
+}
+
+// logAttempt logs the attempt when the client has a RequestLogger
+func (c *Client) logAttempt(ctx context.Context, entry attemptLog, responseBody []byte) {
+	if c.RequestLogger != nil {
+		c.RequestLogger.log(ctx, entry, responseBody)
+	}
+}
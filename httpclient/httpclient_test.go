@@ -2,7 +2,9 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -23,6 +25,13 @@ func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
 
 const testServerUrl = "http://api.test.com"
 
+// noDelayRetryPolicy behaves like the default policy without waiting between attempts
+func noDelayRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy
+	policy.BaseDelay = 0
+	return &policy
+}
+
 func getMockedClientResponse(body string, status int, statusMessage string) (Client, []byte) {
 	expectedResponseBody := []byte(body)
 
@@ -37,8 +46,9 @@ func getMockedClientResponse(body string, status int, statusMessage string) (Cli
 	}
 
 	client := Client{
-		HTTPClient: goClient,
-		baseURL:    testServerUrl,
+		HTTPClient:  goClient,
+		baseURL:     testServerUrl,
+		RetryPolicy: noDelayRetryPolicy(),
 	}
 
 	return client, expectedResponseBody
@@ -53,8 +63,9 @@ func getMockedClientResponseError() Client {
 	}
 
 	client := Client{
-		HTTPClient: goClient,
-		baseURL:    testServerUrl,
+		HTTPClient:  goClient,
+		baseURL:     testServerUrl,
+		RetryPolicy: noDelayRetryPolicy(),
 	}
 
 	return client
@@ -124,8 +135,9 @@ func TestGetRetryWhenError(t *testing.T) {
 	}
 
 	client := Client{
-		HTTPClient: goClient,
-		baseURL:    testServerUrl,
+		HTTPClient:  goClient,
+		baseURL:     testServerUrl,
+		RetryPolicy: noDelayRetryPolicy(),
 	}
 
 	var headers map[string]string
@@ -141,6 +153,58 @@ func TestGetRetryWhenError(t *testing.T) {
 	}
 }
 
+func TestGetWithContextAlreadyCancelled(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer func() { testServer.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client, _ := CreateHTTPClient(testServer.URL)
+	res, err := client.GetWithContext(ctx, nil, nil)
+	if res != nil {
+		t.Errorf("request returning a response with a cancelled context")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("request returning a different error: got %v expected %v", err, context.Canceled)
+	}
+}
+
+func TestRetryStopsWhenContextCancelled(t *testing.T) {
+	callCount := 0
+	ctx, cancel := context.WithCancel(context.Background())
+
+	goClient := &MockClient{
+		MockedDo: func(req *http.Request) (*http.Response, error) {
+			callCount = callCount + 1
+			// cancel while the client is still willing to retry
+			cancel()
+
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Status:     "503 Service Unavailable",
+				Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+			}, nil
+		},
+	}
+
+	client := Client{
+		HTTPClient:  goClient,
+		baseURL:     testServerUrl,
+		RetryPolicy: noDelayRetryPolicy(),
+	}
+
+	err := client.DeleteWithContext(ctx, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("request returning a different error: got %v expected %v", err, context.Canceled)
+	}
+	if callCount != 1 {
+		t.Errorf("Retrying policy not working as expected. Number of calls %v expected %v", callCount, 1)
+	}
+}
+
 func TestCreateHTTPClientWithValidURL(t *testing.T) {
 	validURL := "http://myfakeserver.com:8080"
 	client, err := CreateHTTPClient(validURL)
@@ -176,26 +240,31 @@ func TestGetHeadersAreAdded(t *testing.T) {
 }
 
 func TestGetQueryParamsAreAdded(t *testing.T) {
-	client, _ := getMockedClientResponse("body", http.StatusOK, "")
+	var requestedURLs []string
+	client := Client{
+		HTTPClient: &MockClient{
+			MockedDo: func(req *http.Request) (*http.Response, error) {
+				requestedURLs = append(requestedURLs, req.URL.String())
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			},
+		},
+		baseURL:     testServerUrl,
+		RetryPolicy: noDelayRetryPolicy(),
+	}
 
 	var headers map[string]string
-	queryParams := make(map[string]string)
-
-	param1 := "param1"
-	value1 := "foo"
-	param2 := "param2"
-	value2 := "bar"
-
-	queryParams[param1] = value1
-	queryParams[param2] = value2
-
-	expectedURL := testServerUrl + "?" + param1 + "=" + value1 + "&" + param2 + "=" + value2
-	_, err := client.Get(headers, queryParams)
+	_, err := client.Get(headers, map[string]string{"param1": "foo", "param2": "bar"})
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err.Error())
 	}
-	if client.baseURL != expectedURL {
-		t.Errorf("Query parameters not added correctly to the URL: got %v expected %v", client.baseURL, expectedURL)
+	client.Get(headers, map[string]string{"param3": "baz"})
+
+	expectedURLs := []string{testServerUrl + "?param1=foo&param2=bar", testServerUrl + "?param3=baz"}
+	if len(requestedURLs) != 2 || requestedURLs[0] != expectedURLs[0] || requestedURLs[1] != expectedURLs[1] {
+		t.Errorf("Query parameters not added correctly to the URL: got %v expected %v", requestedURLs, expectedURLs)
+	}
+	if client.baseURL != testServerUrl {
+		t.Errorf("The base URL must not change: got %v expected %v", client.baseURL, testServerUrl)
 	}
 }
 
@@ -307,9 +376,9 @@ func TestPostHeadersAreAdded(t *testing.T) {
 	}
 }
 
-func TestPostRetryWhenError(t *testing.T) {
+func TestPostIsNotRetriedWhenError(t *testing.T) {
 	callCount := 0
-	const retryCountExpected = 11
+	const callCountExpected = 1
 
 	goClient := &MockClient{
 		MockedDo: func(req *http.Request) (*http.Response, error) {
@@ -324,8 +393,9 @@ func TestPostRetryWhenError(t *testing.T) {
 	}
 
 	client := Client{
-		HTTPClient: goClient,
-		baseURL:    testServerUrl,
+		HTTPClient:  goClient,
+		baseURL:     testServerUrl,
+		RetryPolicy: noDelayRetryPolicy(),
 	}
 
 	var headers map[string]string
@@ -336,11 +406,101 @@ func TestPostRetryWhenError(t *testing.T) {
 		t.Errorf("request returning a 200 response status, expected an error")
 	}
 
+	if callCount != callCountExpected {
+		t.Errorf("Retrying policy not working as expected. Number of calls %v expected %v", callCount, callCountExpected)
+	}
+}
+
+func TestPostWithIdempotencyKeyRetryWhenError(t *testing.T) {
+	callCount := 0
+	const retryCountExpected = 11
+
+	goClient := &MockClient{
+		MockedDo: func(req *http.Request) (*http.Response, error) {
+			callCount = callCount + 1
+
+			// the body must be sent again on every attempt
+			body, _ := ioutil.ReadAll(req.Body)
+			if string(body) != "hello world" {
+				t.Errorf("request sent with wrong body on attempt %v: got %v", callCount, string(body))
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Status:     "Internal Server Error",
+				Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+			}, nil
+		},
+	}
+
+	client := Client{
+		HTTPClient:  goClient,
+		baseURL:     testServerUrl,
+		RetryPolicy: noDelayRetryPolicy(),
+	}
+
+	headers := map[string]string{IdempotencyKeyHeader: "a6d5e3c2"}
+	body := []byte("hello world")
+
+	res, _ := client.Post(headers, body)
+	if res != nil {
+		t.Errorf("request returning a 200 response status, expected an error")
+	}
+
 	if callCount != retryCountExpected {
 		t.Errorf("Retrying policy not working as expected. Number of retry %v expected %v", callCount, retryCountExpected)
 	}
 }
 
+func TestPatchOK(t *testing.T) {
+	var method string
+	var sentBody []byte
+
+	goClient := &MockClient{
+		MockedDo: func(req *http.Request) (*http.Response, error) {
+			method = req.Method
+			sentBody, _ = ioutil.ReadAll(req.Body)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString("body")),
+			}, nil
+		},
+	}
+
+	client := Client{
+		HTTPClient: goClient,
+		baseURL:    testServerUrl,
+	}
+
+	body := []byte("hello world")
+	res, err := client.Patch(nil, body)
+	if err != nil {
+		t.Errorf("request returning a non 200 response: got %v", err)
+	}
+	if string(res) != "body" {
+		t.Errorf("request returning a different response body: got %v expected %v", string(res), "body")
+	}
+	if method != http.MethodPatch {
+		t.Errorf("request sent with wrong method: got %v expected %v", method, http.MethodPatch)
+	}
+	if !bytes.Equal(sentBody, body) {
+		t.Errorf("request sent with wrong body: got %v expected %v", string(sentBody), string(body))
+	}
+}
+
+func TestPatchResponseError(t *testing.T) {
+	expectedStatusMessage := "409 Conflict"
+	client, _ := getMockedClientResponse("", http.StatusConflict, expectedStatusMessage)
+
+	res, err := client.Patch(nil, []byte("hello world"))
+	if res != nil {
+		t.Errorf("request returning a 200 response status, expected an error")
+	}
+	if !IsConflict(err) {
+		t.Errorf("request returning a different error status: got %v expected %v", err, expectedStatusMessage)
+	}
+}
+
 func TestDeleteResponseOK(t *testing.T) {
 
 	queryParams := make(map[string]string)
@@ -417,8 +577,9 @@ func TestErrorRetryWhenError(t *testing.T) {
 	}
 
 	client := Client{
-		HTTPClient: goClient,
-		baseURL:    testServerUrl,
+		HTTPClient:  goClient,
+		baseURL:     testServerUrl,
+		RetryPolicy: noDelayRetryPolicy(),
 	}
 
 	var headers map[string]string
@@ -430,3 +591,43 @@ func TestErrorRetryWhenError(t *testing.T) {
 		t.Errorf("Retrying policy not working as expected. Number of retry %v expected %v", callCount, retryCountExpected)
 	}
 }
+
+func TestStreamPassesTheBody(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/missing" {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		res.Write([]byte("streamed body"))
+	}))
+	defer func() { testServer.Close() }()
+
+	client, _ := CreateHTTPClient(testServer.URL)
+
+	var body []byte
+	err := client.Stream(context.Background(), Request{Method: http.MethodGet}, func(r io.Reader) error {
+		var err error
+		body, err = ioutil.ReadAll(r)
+		return err
+	})
+	if err != nil || string(body) != "streamed body" {
+		t.Errorf("Expected the body to be streamed, got %q, %v", body, err)
+	}
+
+	called := false
+	err = client.Stream(context.Background(), Request{Method: http.MethodGet, Path: "/missing"}, func(io.Reader) error {
+		called = true
+		return nil
+	})
+	if !IsNotFound(err) || called {
+		t.Errorf("Expected a 404 APIError without reading the body, got %v", err)
+	}
+
+	readErr := errors.New("read failed")
+	err = client.Stream(context.Background(), Request{Method: http.MethodGet}, func(io.Reader) error {
+		return readErr
+	})
+	if !errors.Is(err, readErr) {
+		t.Errorf("Expected the error of read, got %v", err)
+	}
+}
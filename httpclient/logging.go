@@ -0,0 +1,202 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces the values of the redacted fields in the logged bodies
+const RedactedValue = "[REDACTED]"
+
+// defaultMaxLoggedBodySize is the size of the largest body logged when MaxBodySize is not set
+const defaultMaxLoggedBodySize = 4 * 1024
+
+// RequestLogger logs every attempt of the requests of a Client with a slog.Logger:
+// method, host, path, status, attempt number, latency and, when the request is retried, the reason and the delay.
+// The attempts failing with an error or a 5xx status are logged at Warn level.
+type RequestLogger struct {
+	// Logger receives the records. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Level of the attempts that did not fail. Defaults to Info.
+	Level slog.Level
+
+	// LogBodies adds the request and response bodies to the records.
+	// Only JSON bodies are logged, with the values of the Redact fields replaced.
+	LogBodies bool
+
+	// MaxBodySize is the size in bytes of the largest body logged. Defaults to 4KB.
+	MaxBodySize int
+
+	// Redact lists the JSON fields, at any depth, whose values are never logged
+	Redact []string
+}
+
+// attemptLog describes an attempt of a request
+type attemptLog struct {
+	request     *http.Request
+	requestBody []byte
+	response    *http.Response
+	err         error
+	attempt     int
+	latency     time.Duration
+	retryReason string
+	retryDelay  time.Duration
+}
+
+// captureBody returns the start of the response body to log, the body stays readable by the caller
+func (l *RequestLogger) captureBody(response *http.Response) []byte {
+	if !l.LogBodies || response == nil || response.Body == nil {
+		return nil
+	}
+
+	// one byte more than logged tells a body too large from one of the maximum size
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, int64(l.maxBodySize()+1)))
+	rest := response.Body
+	if err != nil {
+		rest = ioutil.NopCloser(errorReader{err})
+	}
+	response.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), rest), response.Body}
+	return body
+}
+
+// log writes the record of an attempt
+func (l *RequestLogger) log(ctx context.Context, entry attemptLog, responseBody []byte) {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	level := l.Level
+	if entry.err != nil || (entry.response != nil && entry.response.StatusCode >= http.StatusInternalServerError) {
+		level = slog.LevelWarn
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	host := entry.request.Host
+	if host == "" {
+		host = entry.request.URL.Host
+	}
+	attrs := []slog.Attr{
+		slog.String("method", entry.request.Method),
+		slog.String("host", host),
+		slog.String("path", entry.request.URL.Path),
+		slog.Int("attempt", entry.attempt),
+		slog.Duration("latency", entry.latency),
+	}
+	if id := entry.request.Header.Get(RequestIDHeader); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if entry.response != nil {
+		attrs = append(attrs, slog.Int("status", entry.response.StatusCode))
+	}
+	if entry.err != nil {
+		attrs = append(attrs, slog.String("error", errorWithoutQuery(entry.err)))
+	}
+	if entry.retryReason != "" {
+		attrs = append(attrs, slog.String("retry_reason", entry.retryReason), slog.Duration("retry_in", entry.retryDelay))
+	}
+	if l.LogBodies {
+		if len(entry.requestBody) > 0 {
+			attrs = append(attrs, slog.String("request_body", l.redactBody(entry.requestBody)))
+		}
+		if len(responseBody) > 0 {
+			attrs = append(attrs, slog.String("response_body", l.redactBody(responseBody)))
+		}
+	}
+
+	logger.LogAttrs(ctx, level, "http request", attrs...)
+}
+
+// redactBody returns the body to log. Bodies too large or not JSON are left out, they could not be redacted.
+func (l *RequestLogger) redactBody(body []byte) string {
+	if len(body) > l.maxBodySize() {
+		return fmt.Sprintf("<%d bytes or more, not logged>", l.maxBodySize()+1)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("<%d bytes not JSON, not logged>", len(body))
+	}
+
+	fields := make(map[string]bool, len(l.Redact))
+	for _, field := range l.Redact {
+		fields[field] = true
+	}
+
+	var redacted bytes.Buffer
+	encoder := json.NewEncoder(&redacted)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redact(value, fields)); err != nil {
+		return fmt.Sprintf("<%d bytes, not logged>", len(body))
+	}
+	return strings.TrimSuffix(redacted.String(), "\n")
+}
+
+func (l *RequestLogger) maxBodySize() int {
+	if l.MaxBodySize > 0 {
+		return l.MaxBodySize
+	}
+	return defaultMaxLoggedBodySize
+}
+
+// redact replaces the values of the fields in the decoded JSON value
+func redact(value interface{}, fields map[string]bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if fields[key] {
+				value[key] = RedactedValue
+			} else {
+				value[key] = redact(field, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redact(item, fields)
+		}
+	}
+	return value
+}
+
+// errorWithoutQuery returns the message of the error without the query of the url it carries,
+// the filters of a list can hold account numbers and IBANs
+func errorWithoutQuery(err error) string {
+	var urlError *url.Error
+	if !errors.As(err, &urlError) {
+		return err.Error()
+	}
+	requestURL, parseErr := url.Parse(urlError.URL)
+	if parseErr != nil || requestURL.RawQuery == "" {
+		return err.Error()
+	}
+
+	requestURL.RawQuery = ""
+	stripped := *urlError
+	stripped.URL = requestURL.String()
+	return strings.Replace(err.Error(), urlError.Error(), stripped.Error(), 1)
+}
+
+// errorReader fails every read with its error
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
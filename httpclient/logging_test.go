@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logRecords decodes the records written by a slog.JSONHandler
+func logRecords(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func newRequestLogger(output *bytes.Buffer) *RequestLogger {
+	return &RequestLogger{Logger: slog.New(slog.NewJSONHandler(output, nil))}
+}
+
+const accountWithPersonalData = `{"data":{"id":"0d209d7f-d07a-4542-947f-5885fddddae2","organisation_id":"ba61483c-d5c5-4f50-ae81-6b8c039bea43","type":"accounts","attributes":{"country":"GB","iban":"GB16NWBK40030041426819","first_name":"Samantha","alternative_bank_account_names":["Sam Holder"]}}}`
+
+func TestRequestLoggerLogsEveryAttempt(t *testing.T) {
+	var output bytes.Buffer
+	client, _ := newScriptedClient(t, *noDelayRetryPolicy(), errorStatus(503))
+	client.RequestLogger = newRequestLogger(&output)
+
+	if _, err := client.Get(map[string]string{RequestIDHeader: "request-1"}, map[string]string{"page[size]": "10"}); err != nil {
+		t.Fatalf("Request is returning an error: %v", err)
+	}
+
+	records := logRecords(t, &output)
+	if len(records) != 2 {
+		t.Fatalf("Expected a record per attempt, got %v", records)
+	}
+
+	first, second := records[0], records[1]
+	if first["level"] != "WARN" || first["status"] != 503.0 || first["attempt"] != 1.0 || first["retry_reason"] != "status 503" {
+		t.Errorf("Unexpected record of the failed attempt %v", first)
+	}
+	if second["level"] != "INFO" || second["status"] != 200.0 || second["attempt"] != 2.0 || second["retry_reason"] != nil {
+		t.Errorf("Unexpected record of the last attempt %v", second)
+	}
+	if second["method"] != "GET" || second["path"] != "/v1/organisation/accounts" || second["request_id"] != "request-1" {
+		t.Errorf("Unexpected request in the record %v", second)
+	}
+	if _, ok := second["latency"]; !ok {
+		t.Errorf("Expected the latency to be logged, got %v", second)
+	}
+	if _, ok := second["response_body"]; ok {
+		t.Errorf("Expected the bodies not to be logged by default, got %v", second)
+	}
+}
+
+func TestRequestLoggerRedactsBodies(t *testing.T) {
+	var output bytes.Buffer
+	client, _ := newScriptedClient(t, RetryPolicy{MaxAttempts: 1})
+	client.RequestLogger = newRequestLogger(&output)
+	client.RequestLogger.LogBodies = true
+	client.RequestLogger.Redact = []string{"iban", "first_name", "alternative_bank_account_names"}
+
+	body, err := client.Post(map[string]string{"Content-Type": "application/vnd.api+json"}, []byte(accountWithPersonalData))
+	if err != nil {
+		t.Fatalf("Request is returning an error: %v", err)
+	}
+	if !strings.Contains(string(body), "GB16NWBK40030041426819") {
+		t.Errorf("Expected the caller to get the whole response, got %s", body)
+	}
+
+	for _, personalData := range []string{"GB16NWBK40030041426819", "Samantha", "Sam Holder"} {
+		if strings.Contains(output.String(), personalData) {
+			t.Errorf("%v is logged: %v", personalData, output.String())
+		}
+	}
+
+	record := logRecords(t, &output)[0]
+	for _, key := range []string{"request_body", "response_body"} {
+		logged, _ := record[key].(string)
+		if !strings.Contains(logged, `"iban":"[REDACTED]"`) || !strings.Contains(logged, `"country":"GB"`) {
+			t.Errorf("Expected the %v to be logged redacted, got %q", key, logged)
+		}
+	}
+}
+
+func TestRequestLoggerSkipsBodiesItCannotRedact(t *testing.T) {
+	logger := &RequestLogger{MaxBodySize: 16}
+
+	if logged := logger.redactBody([]byte(accountWithPersonalData)); logged != "<17 bytes or more, not logged>" {
+		t.Errorf("Expected a large body not to be logged, got %q", logged)
+	}
+	if logged := logger.redactBody([]byte("iban=GB16NWBK")); logged != "<13 bytes not JSON, not logged>" {
+		t.Errorf("Expected a body that is not JSON not to be logged, got %q", logged)
+	}
+}
+
+func TestRequestLoggerKeepsBodyErrors(t *testing.T) {
+	var output bytes.Buffer
+	client, _ := newScriptedClient(t, RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond}, truncatedBody)
+	client.RequestLogger = newRequestLogger(&output)
+	client.RequestLogger.LogBodies = true
+
+	if _, err := client.Get(nil, nil); err == nil {
+		t.Error("Expected an error reading a truncated body")
+	}
+}
+
+func TestRequestLoggerHidesTheFilters(t *testing.T) {
+	var output bytes.Buffer
+	client, _ := newScriptedClient(t, *noDelayRetryPolicy(), connectionReset, connectionReset)
+	client.RetryPolicy.MaxAttempts = 2
+	client.RequestLogger = newRequestLogger(&output)
+
+	_, err := client.Get(nil, map[string]string{"filter[iban]": "GB16NWBK40030041426819", "filter[account_number]": "41426819"})
+	if err == nil {
+		t.Fatal("Expected the connection reset to be returned")
+	}
+
+	records := logRecords(t, &output)
+	if len(records) != 2 || records[0]["retry_reason"] == nil || records[1]["error"] == nil {
+		t.Fatalf("Expected the retry and the error to be logged, got %v", output.String())
+	}
+	for _, personalData := range []string{"GB16NWBK40030041426819", "41426819", "filter"} {
+		if strings.Contains(output.String(), personalData) {
+			t.Errorf("%v is logged: %v", personalData, output.String())
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics receives the measures of the requests of a Client, e.g. to export them to monitoring.
+// The implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveRequest is called once a request is done, retries included.
+	// statusCode is the status of the last attempt, 0 when the request failed without response.
+	ObserveRequest(operation string, method string, statusCode int, err error, duration time.Duration)
+
+	// ObserveRetry is called before every new attempt of a request with the reason of the retry,
+	// e.g. "status 503" or "transport error"
+	ObserveRetry(operation string, method string, reason string)
+}
+
+type operationKey struct{}
+
+// WithOperation returns a context naming the operation of the requests sent with it, e.g. "create".
+// The operation labels the metrics of the requests.
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext returns the operation set by WithOperation, or an empty string
+func OperationFromContext(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// observeRequest passes the outcome of the request to the metrics of the client
+func (c *Client) observeRequest(ctx context.Context, request *http.Request, response *http.Response, err error, duration time.Duration) {
+	if c.Metrics == nil {
+		return
+	}
+
+	statusCode := 0
+	if response != nil {
+		statusCode = response.StatusCode
+	}
+	c.Metrics.ObserveRequest(OperationFromContext(ctx), request.Method, statusCode, err, duration)
+}
+
+// observeRetry passes the reason of a retry to the metrics of the client.
+// The details of the transport errors are left out, they would make a label per error.
+func (c *Client) observeRetry(ctx context.Context, request *http.Request, reason string) {
+	if c.Metrics == nil {
+		return
+	}
+
+	if i := strings.Index(reason, ":"); i >= 0 {
+		reason = reason[:i]
+	}
+	c.Metrics.ObserveRetry(OperationFromContext(ctx), request.Method, reason)
+}
+
+// statusLabel returns the status code as a label value, "error" for a request without response
+func statusLabel(statusCode int) string {
+	if statusCode == 0 {
+		return "error"
+	}
+	return strconv.Itoa(statusCode)
+}
@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader is the header carrying the ID of a request, also returned by the API in its responses
+const RequestIDHeader = "X-Request-Id"
+
+// Doer sends an http request. It is implemented by http.Client and by every step of a middleware chain.
+type Doer = HttpClient
+
+// DoerFunc adapts a function to a Doer
+type DoerFunc func(request *http.Request) (*http.Response, error)
+
+// Do calls f(request)
+func (f DoerFunc) Do(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// Middleware wraps a Doer, e.g. to change the request or to observe the response.
+// It runs for every attempt of a request, before it is signed, so the signature can cover the headers it sets.
+type Middleware func(next Doer) Doer
+
+// Use appends middlewares to the chain of the client.
+// The first middleware added is the outermost one: it sees the request first and the response last.
+func (c *Client) Use(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+// Chain wraps the doer with the middlewares, the first one being the outermost
+func Chain(doer Doer, middlewares ...Middleware) Doer {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		doer = middlewares[i](doer)
+	}
+	return doer
+}
+
+// Logging logs the method, url, status and duration of every attempt with the logger.
+// The query is left out of the url, the filters of a list can hold account numbers and IBANs.
+func Logging(logger *log.Logger) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(request *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.Do(request)
+			duration := time.Since(start)
+
+			loggedURL := *request.URL
+			loggedURL.RawQuery = ""
+			loggedURL.ForceQuery = false
+			if err != nil {
+				logger.Printf("%v %v failed after %v: %v", request.Method, &loggedURL, duration, errorWithoutQuery(err))
+			} else {
+				logger.Printf("%v %v %v in %v", request.Method, &loggedURL, response.StatusCode, duration)
+			}
+			return response, err
+		})
+	}
+}
+
+// RequestID sets a random X-Request-Id header on the requests without one.
+// The retries of a request keep the ID of the first attempt.
+func RequestID() Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(request *http.Request) (*http.Response, error) {
+			if request.Header.Get(RequestIDHeader) == "" {
+				id, err := newRequestID()
+				if err != nil {
+					return nil, err
+				}
+				request.Header.Set(RequestIDHeader, id)
+			}
+			return next.Do(request)
+		})
+	}
+}
+
+// UserAgent sets the User-Agent header of the requests
+func UserAgent(userAgent string) Middleware {
+	return Headers(map[string]string{"User-Agent": userAgent})
+}
+
+// Headers sets the headers on every request, replacing the values already set
+func Headers(headers map[string]string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(request *http.Request) (*http.Response, error) {
+			for key, value := range headers {
+				request.Header.Set(key, value)
+			}
+			return next.Do(request)
+		})
+	}
+}
+
+// newRequestID returns a random version 4 UUID
+func newRequestID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), nil
+}
@@ -0,0 +1,168 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// recordingDoer answers 200 OK and keeps the requests it receives
+type recordingDoer struct {
+	requests []*http.Request
+}
+
+func (d *recordingDoer) Do(request *http.Request) (*http.Response, error) {
+	d.requests = append(d.requests, request)
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+}
+
+func newRecordingClient() (*Client, *recordingDoer) {
+	doer := &recordingDoer{}
+	return &Client{baseURL: "http://api.test/v1/organisation/accounts", HTTPClient: doer, RetryPolicy: &RetryPolicy{MaxAttempts: 1}}, doer
+}
+
+// tracing returns a middleware appending its name to the trace before and after calling the next doer
+func tracing(name string, trace *[]string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(request *http.Request) (*http.Response, error) {
+			*trace = append(*trace, "> "+name)
+			response, err := next.Do(request)
+			*trace = append(*trace, "< "+name)
+			return response, err
+		})
+	}
+}
+
+func TestMiddlewaresAreAppliedInOrder(t *testing.T) {
+	client, _ := newRecordingClient()
+	var trace []string
+	client.Use(tracing("first", &trace))
+	client.Use(tracing("second", &trace), tracing("third", &trace))
+
+	if _, err := client.Get(nil, nil); err != nil {
+		t.Fatalf("Request is returning an error: %v", err)
+	}
+
+	expected := "> first,> second,> third,< third,< second,< first"
+	if strings.Join(trace, ",") != expected {
+		t.Errorf("Expected %v but got %v", expected, strings.Join(trace, ","))
+	}
+}
+
+func TestMiddlewareCanShortCircuit(t *testing.T) {
+	client, doer := newRecordingClient()
+	refused := errors.New("refused")
+	client.Use(func(next Doer) Doer {
+		return DoerFunc(func(request *http.Request) (*http.Response, error) {
+			return nil, refused
+		})
+	})
+
+	if _, err := client.Get(nil, nil); !errors.Is(err, refused) {
+		t.Errorf("Expected the middleware error but got %v", err)
+	}
+	if len(doer.requests) != 0 {
+		t.Errorf("Expected the request not to be sent, got %v requests", len(doer.requests))
+	}
+}
+
+func TestMiddlewaresRunForEveryAttempt(t *testing.T) {
+	client, _ := newScriptedClient(t, *noDelayRetryPolicy(), errorStatus(503), errorStatus(503))
+	var ids []string
+	client.Use(RequestID(), func(next Doer) Doer {
+		return DoerFunc(func(request *http.Request) (*http.Response, error) {
+			ids = append(ids, request.Header.Get(RequestIDHeader))
+			return next.Do(request)
+		})
+	})
+
+	if _, err := client.Get(nil, nil); err != nil {
+		t.Fatalf("Request is returning an error: %v", err)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("Expected the middlewares to see 3 attempts, got %v", len(ids))
+	}
+	if ids[0] == "" || ids[1] != ids[0] || ids[2] != ids[0] {
+		t.Errorf("Expected the retries to keep the request ID, got %v", ids)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	client, doer := newRecordingClient()
+	client.Use(RequestID())
+
+	client.Get(nil, nil)
+	client.Get(nil, nil)
+	client.Get(map[string]string{RequestIDHeader: "given"}, nil)
+
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	first := doer.requests[0].Header.Get(RequestIDHeader)
+	if !uuidPattern.MatchString(first) {
+		t.Errorf("Expected a UUID request ID but got %q", first)
+	}
+	if doer.requests[1].Header.Get(RequestIDHeader) == first {
+		t.Errorf("Expected every request to get its own ID")
+	}
+	if id := doer.requests[2].Header.Get(RequestIDHeader); id != "given" {
+		t.Errorf("Expected the request ID set by the caller to be kept, got %q", id)
+	}
+}
+
+func TestHeaderMiddlewares(t *testing.T) {
+	client, doer := newRecordingClient()
+	client.Use(UserAgent("accounts-cli/1.0"), Headers(map[string]string{"X-Tenant": "form3", "Accept": "application/json"}))
+
+	client.Get(map[string]string{"Accept": "application/vnd.api+json", "User-Agent": "other"}, nil)
+
+	header := doer.requests[0].Header
+	if header.Get("User-Agent") != "accounts-cli/1.0" {
+		t.Errorf("Expected the user agent of the middleware but got %q", header.Get("User-Agent"))
+	}
+	if header.Get("X-Tenant") != "form3" || header.Get("Accept") != "application/json" {
+		t.Errorf("Expected the headers of the middleware to be set, got %v", header)
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var output bytes.Buffer
+	client, _ := newRecordingClient()
+	client.Use(Logging(log.New(&output, "", 0)))
+
+	client.Get(nil, nil)
+
+	if !strings.HasPrefix(output.String(), "GET http://api.test/v1/organisation/accounts 200 in ") {
+		t.Errorf("Unexpected log %q", output.String())
+	}
+
+	output.Reset()
+	failing := Chain(DoerFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}), Logging(log.New(&output, "", 0)))
+	request, _ := http.NewRequest(http.MethodDelete, "http://api.test/", nil)
+	failing.Do(request)
+
+	if !strings.Contains(output.String(), "DELETE http://api.test/ failed after") || !strings.Contains(output.String(), "connection refused") {
+		t.Errorf("Unexpected log %q", output.String())
+	}
+
+	output.Reset()
+	client.Get(nil, map[string]string{"filter[iban]": "GB16NWBK40030041426819"})
+	if !strings.HasPrefix(output.String(), "GET http://api.test/v1/organisation/accounts 200 in ") || strings.Contains(output.String(), "GB16NWBK40030041426819") {
+		t.Errorf("Expected the filters to be left out of the log, got %q", output.String())
+	}
+
+	output.Reset()
+	failing = Chain(DoerFunc(func(request *http.Request) (*http.Response, error) {
+		return nil, &url.Error{Op: "Get", URL: request.URL.String(), Err: errors.New("connection refused")}
+	}), Logging(log.New(&output, "", 0)))
+	request, _ = http.NewRequest(http.MethodGet, "http://api.test/?filter%5Baccount_number%5D=41426819", nil)
+	failing.Do(request)
+	if strings.Contains(output.String(), "41426819") || !strings.Contains(output.String(), "connection refused") {
+		t.Errorf("Expected the filters to be left out of the logged error, got %q", output.String())
+	}
+}
@@ -0,0 +1,244 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authorizer adds the credentials of the caller to a request, e.g. a bearer token.
+// The Client authorizes every attempt before signing it.
+type Authorizer interface {
+	Authorize(request *http.Request) error
+}
+
+// TokenInvalidator is implemented by the authorizers using a token the API can refuse, e.g. an expired one.
+// The Client invalidates the token and retries once with a new one when a request gets 401 Unauthorized.
+type TokenInvalidator interface {
+	InvalidateToken()
+}
+
+// TokenError is returned when the token endpoint refuses to issue a token
+type TokenError struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *TokenError) Error() string {
+	message := "token request failed with status " + fmt.Sprint(e.StatusCode)
+	if e.Code != "" {
+		message = message + ": " + e.Code
+	}
+	if e.Description != "" {
+		message = message + " (" + e.Description + ")"
+	}
+	return message
+}
+
+// ClientCredentials authenticates the requests with a bearer token obtained through the OAuth2 client credentials grant.
+//
+// The token is cached and shared by the requests until it is about to expire.
+// Once a request comes within RefreshBefore of its expiry, a new token is requested in the background
+// while the requests keep using the current one. A request waits for a token only when there is none,
+// it has expired or the API answered 401 Unauthorized.
+// ClientCredentials is safe for concurrent use, a single token request is in flight at a time.
+type ClientCredentials struct {
+	// TokenURL is the token endpoint of the authorization server
+	TokenURL string
+
+	ClientID     string
+	ClientSecret string
+
+	// Scopes requested for the token, none when empty
+	Scopes []string
+
+	// HTTPClient calls the token endpoint. Defaults to the client of CreateHTTPClient.
+	HTTPClient HttpClient
+
+	// RefreshBefore is how long before its expiry a token is replaced in the background. Defaults to one minute.
+	RefreshBefore time.Duration
+
+	// lock is a channel rather than a mutex so that waiting for it honours the context
+	lock       chan struct{}
+	lockOnce   sync.Once
+	token      string
+	expiry     time.Time
+	refreshing bool
+	now        func() time.Time
+}
+
+// NewClientCredentials returns the ClientCredentials of a client of the token endpoint
+func NewClientCredentials(tokenURL string, clientID string, clientSecret string, scopes ...string) *ClientCredentials {
+	return &ClientCredentials{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+	}
+}
+
+// tokenResponse is the successful response of the token endpoint
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Authorize sets the Authorization header of the request with a valid bearer token
+func (c *ClientCredentials) Authorize(request *http.Request) error {
+	token, err := c.Token(request.Context())
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns the cached token, or requests a new one if it is missing or has expired.
+// A token about to expire is returned while a new one is requested in the background.
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	if err := c.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer c.release()
+
+	refreshBefore := c.RefreshBefore
+	if refreshBefore <= 0 {
+		refreshBefore = time.Minute
+	}
+	now := c.clock()
+	// a token without expiry is kept until the API refuses it
+	if c.token != "" && (c.expiry.IsZero() || now.Add(refreshBefore).Before(c.expiry)) {
+		return c.token, nil
+	}
+	if c.token != "" && now.Before(c.expiry) {
+		if !c.refreshing {
+			c.refreshing = true
+			go c.refresh()
+		}
+		return c.token, nil
+	}
+
+	token, expiresIn, err := c.requestToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.setToken(token, expiresIn)
+	return c.token, nil
+}
+
+// refresh replaces the token about to expire, no request waits for it.
+// On failure the current token stays in use and the next request tries again.
+func (c *ClientCredentials) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(requestTimeout)*time.Second)
+	defer cancel()
+	token, expiresIn, err := c.requestToken(ctx)
+
+	c.acquire(context.Background())
+	defer c.release()
+
+	c.refreshing = false
+	if err == nil {
+		c.setToken(token, expiresIn)
+	}
+}
+
+// setToken caches the token, the lock must be held
+func (c *ClientCredentials) setToken(token string, expiresIn time.Duration) {
+	c.token = token
+	c.expiry = time.Time{}
+	if expiresIn > 0 {
+		c.expiry = c.clock().Add(expiresIn)
+	}
+}
+
+// InvalidateToken drops the cached token, the next request gets a new one
+func (c *ClientCredentials) InvalidateToken() {
+	c.acquire(context.Background())
+	defer c.release()
+
+	c.token = ""
+}
+
+func (c *ClientCredentials) acquire(ctx context.Context) error {
+	c.lockOnce.Do(func() {
+		c.lock = make(chan struct{}, 1)
+	})
+
+	select {
+	case c.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *ClientCredentials) release() {
+	<-c.lock
+}
+
+// requestToken calls the token endpoint with the client credentials grant
+func (c *ClientCredentials) requestToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return "", 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+	if err != nil {
+		return "", 0, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		tokenError := &TokenError{StatusCode: response.StatusCode}
+		json.Unmarshal(body, tokenError)
+		return "", 0, tokenError
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, fmt.Errorf("invalid token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, fmt.Errorf("invalid token response: no access token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
+
+func (c *ClientCredentials) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,213 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newProtectedAPI returns the url of an API needing the tokens of its token server
+func newProtectedAPI(t *testing.T) (string, *tokenServer) {
+	tokens := &tokenServer{tokens: make(map[string]bool)}
+	server := httptest.NewServer(tokens)
+	t.Cleanup(server.Close)
+	return server.URL, tokens
+}
+
+func newOAuthClient(serverURL string, credentials *ClientCredentials) *Client {
+	return &Client{
+		HTTPClient:  &http.Client{},
+		baseURL:     serverURL + "/v1/organisation/accounts",
+		RetryPolicy: noDelayRetryPolicy(),
+		Authorizer:  credentials,
+	}
+}
+
+func TestClientCredentialsTokenIsCached(t *testing.T) {
+	serverURL, tokens := newProtectedAPI(t)
+	client := newOAuthClient(serverURL, NewClientCredentials(serverURL+testTokenPath, "client", "s3cret", "accounts"))
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(nil, nil); err != nil {
+			t.Fatalf("Request %d is returning an error: %v", i, err)
+		}
+	}
+	if tokens.Issued() != 1 {
+		t.Errorf("Expected the token to be reused, got %v tokens issued", tokens.Issued())
+	}
+}
+
+func TestClientCredentialsRefreshBeforeExpiry(t *testing.T) {
+	serverURL, tokens := newProtectedAPI(t)
+	clock := &fakeClock{now: time.Now()}
+	credentials := NewClientCredentials(serverURL+testTokenPath, "client", "s3cret")
+	credentials.RefreshBefore = 5 * time.Minute
+	credentials.now = clock.Now
+	client := newOAuthClient(serverURL, credentials)
+
+	client.Get(nil, nil)
+	clock.Advance(54 * time.Minute)
+	client.Get(nil, nil)
+	if tokens.Issued() != 1 {
+		t.Errorf("Expected the token to be used until 5 minutes before expiry, got %v tokens issued", tokens.Issued())
+	}
+
+	clock.Advance(2 * time.Minute)
+	if _, err := client.Get(nil, nil); err != nil {
+		t.Fatalf("Request is returning an error: %v", err)
+	}
+	for deadline := time.Now().Add(time.Second); tokens.Issued() != 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if tokens.Issued() != 2 {
+		t.Errorf("Expected the token to be refreshed before expiry, got %v tokens issued", tokens.Issued())
+	}
+}
+
+func TestClientCredentialsRefreshInTheBackground(t *testing.T) {
+	release := make(chan struct{})
+	var mutex sync.Mutex
+	issued := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		issued = issued + 1
+		token := issued
+		mutex.Unlock()
+		// the second token is only issued once the test has checked that nobody waits for it
+		if token == 2 {
+			<-release
+		}
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":600}`, token)
+	}))
+	t.Cleanup(server.Close)
+
+	clock := &fakeClock{now: time.Now()}
+	credentials := NewClientCredentials(server.URL, "client", "s3cret")
+	credentials.RefreshBefore = 5 * time.Minute
+	credentials.now = clock.Now
+
+	if token, err := credentials.Token(context.Background()); err != nil || token != "token-1" {
+		t.Fatalf("Expected the first token but got %v, %v", token, err)
+	}
+
+	clock.Advance(6 * time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if token, err := credentials.Token(ctx); err != nil || token != "token-1" {
+		t.Fatalf("Expected the current token while the new one is requested, got %v, %v", token, err)
+	}
+	if token, err := credentials.Token(ctx); err != nil || token != "token-1" {
+		t.Fatalf("Expected the current token while the new one is requested, got %v, %v", token, err)
+	}
+
+	close(release)
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if token, _ := credentials.Token(ctx); token == "token-2" {
+			break
+		}
+	}
+	if token, _ := credentials.Token(ctx); token != "token-2" {
+		t.Errorf("Expected the token refreshed in the background, got %v", token)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if issued != 2 {
+		t.Errorf("Expected a single refresh, got %v tokens issued", issued)
+	}
+}
+
+func TestClientCredentialsRefreshOnUnauthorized(t *testing.T) {
+	serverURL, tokens := newProtectedAPI(t)
+	policy := RetryPolicy{MaxAttempts: 1}
+	client := newOAuthClient(serverURL, NewClientCredentials(serverURL+testTokenPath, "client", "s3cret"))
+	client.RetryPolicy = &policy
+
+	client.Get(nil, nil)
+	tokens.RevokeAll()
+
+	// the refused token is replaced even when the policy does not retry
+	if _, err := client.Get(nil, nil); err != nil {
+		t.Fatalf("Expected the request to succeed with a new token, got %v", err)
+	}
+	if tokens.Issued() != 2 {
+		t.Errorf("Expected a new token after the 401, got %v tokens issued", tokens.Issued())
+	}
+}
+
+func TestClientCredentialsRefreshOnlyOnce(t *testing.T) {
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == testTokenPath {
+			res.Write([]byte(`{"access_token":"token","token_type":"bearer","expires_in":3600}`))
+			return
+		}
+		callCount = callCount + 1
+		res.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer func() { testServer.Close() }()
+
+	client := newOAuthClient(testServer.URL, NewClientCredentials(testServer.URL+testTokenPath, "client", "s3cret"))
+
+	_, err := client.Get(nil, nil)
+	var apiError *APIError
+	if !errors.As(err, &apiError) || apiError.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected the 401 to be returned, got %v", err)
+	}
+	if callCount != 2 {
+		t.Errorf("Expected a single retry with a new token, got %v calls", callCount)
+	}
+}
+
+func TestClientCredentialsTokenError(t *testing.T) {
+	serverURL, _ := newProtectedAPI(t)
+	client := newOAuthClient(serverURL, NewClientCredentials(serverURL+testTokenPath, "client", "wrong"))
+
+	_, err := client.Get(nil, nil)
+	var tokenError *TokenError
+	if !errors.As(err, &tokenError) {
+		t.Fatalf("Expected a TokenError but got %v", err)
+	}
+	if tokenError.StatusCode != http.StatusUnauthorized || tokenError.Code != "invalid_client" {
+		t.Errorf("Unexpected token error %+v", tokenError)
+	}
+}
+
+func TestClientCredentialsConcurrentRequestsShareToken(t *testing.T) {
+	serverURL, tokens := newProtectedAPI(t)
+	credentials := &ClientCredentials{TokenURL: serverURL + testTokenPath, ClientID: "client", ClientSecret: "s3cret"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := newOAuthClient(serverURL, credentials)
+			if _, err := client.Get(nil, nil); err != nil {
+				t.Errorf("Request is returning an error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if tokens.Issued() != 1 {
+		t.Errorf("Expected a single token request, got %v tokens issued", tokens.Issued())
+	}
+}
+
+func TestClientCredentialsTokenHonoursContext(t *testing.T) {
+	credentials := NewClientCredentials("http://api.test/token", "client", "s3cret")
+	credentials.acquire(context.Background())
+	defer credentials.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := credentials.Token(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded while waiting for the token, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the buckets of the latency histogram
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// PrometheusMetrics keeps the metrics of the requests in memory and exports them in the Prometheus text format:
+//
+//	<namespace>_requests_total{operation,method,status}        requests done, by status of the last attempt
+//	<namespace>_request_errors_total{operation,method,status}  requests failed with an error or a 4xx or 5xx status
+//	<namespace>_retries_total{operation,method,reason}         new attempts of the requests
+//	<namespace>_request_duration_seconds{operation,method}     histogram of the duration of the requests, retries included
+//
+// The status is "error" for the requests failed without response.
+// It serves the metrics over http, e.g. on /metrics, and is safe for concurrent use.
+type PrometheusMetrics struct {
+	// Namespace prefixes the names of the metrics. Defaults to "httpclient".
+	Namespace string
+
+	// Buckets of the latency histogram, in seconds and in increasing order. Defaults to DefaultLatencyBuckets.
+	// They must not be changed once a request is observed.
+	Buckets []float64
+
+	mutex     sync.Mutex
+	requests  map[requestSeries]uint64
+	errors    map[requestSeries]uint64
+	retries   map[retrySeries]uint64
+	durations map[durationSeries]*histogram
+}
+
+type requestSeries struct {
+	operation string
+	method    string
+	status    string
+}
+
+type retrySeries struct {
+	operation string
+	method    string
+	reason    string
+}
+
+type durationSeries struct {
+	operation string
+	method    string
+}
+
+// histogram counts the observations of each bucket, the last count is for the values above every bucket
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewPrometheusMetrics returns PrometheusMetrics whose metric names start with the namespace
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	return &PrometheusMetrics{Namespace: namespace}
+}
+
+// ObserveRequest implements Metrics
+func (m *PrometheusMetrics) ObserveRequest(operation string, method string, statusCode int, err error, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.requests == nil {
+		m.requests = make(map[requestSeries]uint64)
+		m.errors = make(map[requestSeries]uint64)
+		m.durations = make(map[durationSeries]*histogram)
+	}
+
+	series := requestSeries{operation: operation, method: method, status: statusLabel(statusCode)}
+	m.requests[series]++
+	if err != nil || statusCode >= http.StatusBadRequest {
+		m.errors[series]++
+	}
+
+	buckets := m.buckets()
+	h, ok := m.durations[durationSeries{operation: operation, method: method}]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets)+1)}
+		m.durations[durationSeries{operation: operation, method: method}] = h
+	}
+	seconds := duration.Seconds()
+	h.counts[sort.SearchFloat64s(buckets, seconds)]++
+	h.sum += seconds
+	h.count++
+}
+
+// ObserveRetry implements Metrics
+func (m *PrometheusMetrics) ObserveRetry(operation string, method string, reason string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.retries == nil {
+		m.retries = make(map[retrySeries]uint64)
+	}
+	m.retries[retrySeries{operation: operation, method: method, reason: reason}]++
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mutex.Lock()
+	var buffer bytes.Buffer
+	m.writeRequests(&buffer, "requests_total", "Requests done, retries included, by status of the last attempt.", m.requests)
+	m.writeRequests(&buffer, "request_errors_total", "Requests failed with an error or a 4xx or 5xx status.", m.errors)
+	m.writeRetries(&buffer)
+	m.writeDurations(&buffer)
+	m.mutex.Unlock()
+
+	return buffer.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics to the Prometheus scraper
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", PrometheusContentType)
+	m.WriteTo(w)
+}
+
+func (m *PrometheusMetrics) writeRequests(w io.Writer, name string, help string, counters map[requestSeries]uint64) {
+	name = m.name(name)
+	writeHeader(w, name, help, "counter")
+
+	lines := make([]string, 0, len(counters))
+	for s, count := range counters {
+		lines = append(lines, fmt.Sprintf("%s%s %d\n", name, labels("operation", s.operation, "method", s.method, "status", s.status), count))
+	}
+	sort.Strings(lines)
+	io.WriteString(w, strings.Join(lines, ""))
+}
+
+func (m *PrometheusMetrics) writeRetries(w io.Writer) {
+	name := m.name("retries_total")
+	writeHeader(w, name, "New attempts of the requests, by reason.", "counter")
+
+	lines := make([]string, 0, len(m.retries))
+	for s, count := range m.retries {
+		lines = append(lines, fmt.Sprintf("%s%s %d\n", name, labels("operation", s.operation, "method", s.method, "reason", s.reason), count))
+	}
+	sort.Strings(lines)
+	io.WriteString(w, strings.Join(lines, ""))
+}
+
+func (m *PrometheusMetrics) writeDurations(w io.Writer) {
+	name := m.name("request_duration_seconds")
+	writeHeader(w, name, "Duration of the requests, retries included.", "histogram")
+
+	series := make([]durationSeries, 0, len(m.durations))
+	for s := range m.durations {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].operation != series[j].operation {
+			return series[i].operation < series[j].operation
+		}
+		return series[i].method < series[j].method
+	})
+
+	buckets := m.buckets()
+	for _, s := range series {
+		h := m.durations[s]
+		var cumulative uint64
+		for i, bound := range buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels("operation", s.operation, "method", s.method, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels("operation", s.operation, "method", s.method, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, labels("operation", s.operation, "method", s.method), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, labels("operation", s.operation, "method", s.method), h.count)
+	}
+}
+
+func (m *PrometheusMetrics) name(name string) string {
+	namespace := m.Namespace
+	if namespace == "" {
+		namespace = "httpclient"
+	}
+	return namespace + "_" + name
+}
+
+func (m *PrometheusMetrics) buckets() []float64 {
+	if len(m.Buckets) > 0 {
+		return m.Buckets
+	}
+	return DefaultLatencyBuckets
+}
+
+func writeHeader(w io.Writer, name string, help string, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// labels formats the pairs of names and values as the labels of a sample
+func labels(pairs ...string) string {
+	var builder strings.Builder
+	builder.WriteString("{")
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(pairs[i] + `="` + labelEscaper.Replace(pairs[i+1]) + `"`)
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
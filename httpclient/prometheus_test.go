@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetricsOfRequests(t *testing.T) {
+	metrics := NewPrometheusMetrics("accounts")
+	client, _ := newScriptedClient(t, *noDelayRetryPolicy(), errorStatus(503), errorStatus(503), accounts, errorStatus(404))
+	client.Metrics = metrics
+
+	ctx := WithOperation(context.Background(), "list")
+	if _, err := client.GetWithContext(ctx, nil, nil); err != nil {
+		t.Fatalf("Request is returning an error: %v", err)
+	}
+	client.GetWithContext(ctx, nil, nil)
+
+	var output strings.Builder
+	metrics.WriteTo(&output)
+	for _, sample := range []string{
+		`accounts_requests_total{operation="list",method="GET",status="200"} 1`,
+		`accounts_requests_total{operation="list",method="GET",status="404"} 1`,
+		`accounts_request_errors_total{operation="list",method="GET",status="404"} 1`,
+		`accounts_retries_total{operation="list",method="GET",reason="status 503"} 2`,
+		`accounts_request_duration_seconds_bucket{operation="list",method="GET",le="+Inf"} 2`,
+		`accounts_request_duration_seconds_count{operation="list",method="GET"} 2`,
+		"# TYPE accounts_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(output.String(), sample+"\n") {
+			t.Errorf("Expected %v in the metrics:\n%v", sample, output.String())
+		}
+	}
+	if strings.Contains(output.String(), `accounts_request_errors_total{operation="list",method="GET",status="200"}`) {
+		t.Errorf("Expected the successful requests not to count as errors:\n%v", output.String())
+	}
+}
+
+func TestPrometheusMetricsHistogram(t *testing.T) {
+	metrics := &PrometheusMetrics{Buckets: []float64{0.1, 1}}
+	metrics.ObserveRequest("fetch", "GET", 200, nil, 50*time.Millisecond)
+	metrics.ObserveRequest("fetch", "GET", 200, nil, time.Second)
+	metrics.ObserveRequest("fetch", "GET", 0, errors.New("connection reset"), 2*time.Second)
+
+	var output strings.Builder
+	metrics.WriteTo(&output)
+	expected := `httpclient_request_duration_seconds_bucket{operation="fetch",method="GET",le="0.1"} 1
+httpclient_request_duration_seconds_bucket{operation="fetch",method="GET",le="1"} 2
+httpclient_request_duration_seconds_bucket{operation="fetch",method="GET",le="+Inf"} 3
+httpclient_request_duration_seconds_sum{operation="fetch",method="GET"} 3.05
+httpclient_request_duration_seconds_count{operation="fetch",method="GET"} 3
+`
+	if !strings.HasSuffix(output.String(), expected) {
+		t.Errorf("Expected the histogram\n%v\nbut got\n%v", expected, output.String())
+	}
+	if !strings.Contains(output.String(), `httpclient_request_errors_total{operation="fetch",method="GET",status="error"} 1`) {
+		t.Errorf("Expected the request without response to count as an error:\n%v", output.String())
+	}
+}
+
+func TestPrometheusMetricsHandler(t *testing.T) {
+	metrics := NewPrometheusMetrics("accounts")
+	metrics.ObserveRetry("create", "POST", "status \"429\"\n")
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	if recorder.Header().Get("Content-Type") != PrometheusContentType {
+		t.Errorf("Unexpected content type %v", recorder.Header().Get("Content-Type"))
+	}
+	body, _ := ioutil.ReadAll(recorder.Body)
+	if !strings.Contains(string(body), `accounts_retries_total{operation="create",method="POST",reason="status \"429\"\n"} 1`) {
+		t.Errorf("Expected the label values to be escaped:\n%s", body)
+	}
+}
+
+func TestRetryReasonOfTransportErrorsHasNoDetails(t *testing.T) {
+	metrics := NewPrometheusMetrics("accounts")
+	client, _ := newScriptedClient(t, *noDelayRetryPolicy(), connectionReset)
+	client.Metrics = metrics
+
+	if _, err := client.Get(nil, nil); err != nil {
+		t.Fatalf("Request is returning an error: %v", err)
+	}
+
+	var output strings.Builder
+	metrics.WriteTo(&output)
+	if !strings.Contains(output.String(), `accounts_retries_total{operation="",method="GET",reason="transport error"} 1`) {
+		t.Errorf("Expected the retry after the transport error:\n%v", output.String())
+	}
+}
@@ -0,0 +1,240 @@
+package httpclient
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// headers sent by the API to describe the rate limit of the caller
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// Rate is a number of requests allowed over a period, e.g. 100 requests per second
+type Rate struct {
+	// Requests is the number of requests allowed every Period. Zero means no limit.
+	Requests int
+
+	// Period defaults to one second
+	Period time.Duration
+
+	// Burst is the number of requests that can be sent at once. Defaults to Requests.
+	Burst int
+}
+
+// RateLimiter delays the requests to stay below a rate, using a token bucket.
+//
+// The rate is chosen per host and per method with SetRate, the Default rate applies to the others.
+// Every host has its own buckets. When the API returns the X-RateLimit-Limit, X-RateLimit-Remaining
+// and X-RateLimit-Reset headers the limiter also waits for the reset once the remaining budget is exhausted,
+// whatever the configured rate. The budget of the server is the one of the host, shared by every method.
+//
+// The requests over the budget are blocked until a token is available or their context is done.
+// A RateLimiter is safe for concurrent use and is meant to be shared by the clients calling the same hosts.
+type RateLimiter struct {
+	// Default is the rate of the hosts and methods without a rate of their own
+	Default Rate
+
+	mutex   sync.Mutex
+	rates   map[rateKey]Rate
+	buckets map[rateKey]*tokenBucket
+	servers map[string]*serverBudget
+	now     func() time.Time
+}
+
+// rateKey identifies a rate or a bucket. An empty host or method matches every host or method.
+type rateKey struct {
+	host   string
+	method string
+}
+
+// tokenBucket holds the budget of a host, for every method or a single one
+type tokenBucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// serverBudget is the budget of a host announced by the server, known until reset
+type serverBudget struct {
+	remaining int
+	reset     time.Time
+}
+
+// NewRateLimiter returns a RateLimiter applying the rate to every host and method
+func NewRateLimiter(rate Rate) *RateLimiter {
+	return &RateLimiter{Default: rate}
+}
+
+// SetRate sets the rate of the requests with the method sent to the host.
+// An empty host applies the rate to every host, each one with its own budget,
+// and an empty method applies it to every method, sharing the same budget.
+// The most specific rate wins: host and method, then host, then method, then Default.
+func (l *RateLimiter) SetRate(host string, method string, rate Rate) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.rates == nil {
+		l.rates = make(map[rateKey]Rate)
+	}
+	l.rates[rateKey{host: host, method: method}] = rate
+
+	// the budgets restart from the new rates
+	l.buckets = nil
+}
+
+// Wait blocks until the request can be sent to the host without exceeding its rate.
+// It returns the error of the context if it is done first.
+func (l *RateLimiter) Wait(ctx context.Context, host string, method string) error {
+	for {
+		l.mutex.Lock()
+		delay := l.reserve(host, method)
+		l.mutex.Unlock()
+
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Update adjusts the budget of the host from the rate limit headers of the response
+func (l *RateLimiter) Update(host string, method string, response *http.Response) {
+	if response == nil {
+		return
+	}
+
+	remaining, err := strconv.Atoi(response.Header.Get(RateLimitRemainingHeader))
+	if err != nil {
+		return
+	}
+	reset, ok := parseRateLimitReset(response.Header.Get(RateLimitResetHeader), l.clock())
+	if !ok {
+		return
+	}
+	if limit, err := strconv.Atoi(response.Header.Get(RateLimitLimitHeader)); err == nil && remaining > limit {
+		remaining = limit
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.servers == nil {
+		l.servers = make(map[string]*serverBudget)
+	}
+	l.servers[host] = &serverBudget{remaining: remaining, reset: reset}
+
+	// the server knows better, no method of the host sends more than it allows
+	l.bucket(host, method)
+	for key, bucket := range l.buckets {
+		if key.host == host && bucket.rate.Requests > 0 && float64(remaining) < bucket.tokens {
+			bucket.tokens = float64(remaining)
+		}
+	}
+}
+
+// reserve takes a token for the request or returns how long to wait before trying again
+func (l *RateLimiter) reserve(host string, method string) time.Duration {
+	bucket := l.bucket(host, method)
+	now := l.clock()
+
+	// the budget announced by the server is refilled on reset
+	server := l.servers[host]
+	if server != nil && !now.Before(server.reset) {
+		delete(l.servers, host)
+		server = nil
+	}
+	if server != nil && server.remaining <= 0 {
+		return server.reset.Sub(now)
+	}
+
+	if bucket.rate.Requests > 0 {
+		period := bucket.rate.Period
+		if period <= 0 {
+			period = time.Second
+		}
+		perToken := float64(period) / float64(bucket.rate.Requests)
+
+		burst := bucket.rate.Burst
+		if burst <= 0 {
+			burst = bucket.rate.Requests
+		}
+
+		bucket.tokens = math.Min(float64(burst), bucket.tokens+float64(now.Sub(bucket.last))/perToken)
+		bucket.last = now
+
+		if bucket.tokens < 1 {
+			return time.Duration(math.Ceil((1 - bucket.tokens) * perToken))
+		}
+		bucket.tokens = bucket.tokens - 1
+	}
+
+	if server != nil {
+		server.remaining = server.remaining - 1
+	}
+	return 0
+}
+
+// bucket returns the bucket of the rate matching the host and method, created full
+func (l *RateLimiter) bucket(host string, method string) *tokenBucket {
+	rate, key := l.rate(host, method)
+
+	if l.buckets == nil {
+		l.buckets = make(map[rateKey]*tokenBucket)
+	}
+	bucket, ok := l.buckets[key]
+	if !ok {
+		burst := rate.Burst
+		if burst <= 0 {
+			burst = rate.Requests
+		}
+		bucket = &tokenBucket{rate: rate, tokens: float64(burst), last: l.clock()}
+		l.buckets[key] = bucket
+	}
+	return bucket
+}
+
+// rate returns the most specific rate of the request and the key of its bucket
+func (l *RateLimiter) rate(host string, method string) (Rate, rateKey) {
+	for _, key := range []rateKey{{host, method}, {host, ""}, {"", method}} {
+		if rate, ok := l.rates[key]; ok {
+			// a rate set for every host still has a budget per host
+			return rate, rateKey{host: host, method: key.method}
+		}
+	}
+	return l.Default, rateKey{host: host}
+}
+
+func (l *RateLimiter) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+// parseRateLimitReset reads X-RateLimit-Reset, either a unix time or a number of seconds from now
+func parseRateLimitReset(value string, now time.Time) (time.Time, bool) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, false
+	}
+
+	// a number of seconds this large can only be a date
+	if seconds > 1e9 {
+		return time.Unix(0, int64(seconds*float64(time.Second))), true
+	}
+	return now.Add(time.Duration(seconds * float64(time.Second))), true
+}
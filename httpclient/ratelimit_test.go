@@ -0,0 +1,238 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(rate Rate) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := NewRateLimiter(rate)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestRateLimiterBurstThenRate(t *testing.T) {
+	limiter, clock := newTestLimiter(Rate{Requests: 10, Period: time.Second, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if delay := limiter.reserve(testHost, http.MethodGet); delay != 0 {
+			t.Fatalf("Request %d of the burst delayed by %v", i, delay)
+		}
+	}
+	if delay := limiter.reserve(testHost, http.MethodGet); delay != 100*time.Millisecond {
+		t.Errorf("Expected to wait 100ms for the next token but got %v", delay)
+	}
+
+	clock.Advance(100 * time.Millisecond)
+	if delay := limiter.reserve(testHost, http.MethodGet); delay != 0 {
+		t.Errorf("Expected a token after 100ms but got a delay of %v", delay)
+	}
+}
+
+func TestRateLimiterNoLimitByDefault(t *testing.T) {
+	limiter := &RateLimiter{}
+
+	for i := 0; i < 1000; i++ {
+		if delay := limiter.reserve(testHost, http.MethodPost); delay != 0 {
+			t.Fatalf("The zero value must not limit, got a delay of %v", delay)
+		}
+	}
+}
+
+func TestRateLimiterPerHostAndMethod(t *testing.T) {
+	limiter, _ := newTestLimiter(Rate{Requests: 1, Period: time.Minute})
+	limiter.SetRate(testHost, http.MethodPost, Rate{Requests: 2, Period: time.Minute})
+	limiter.SetRate("", http.MethodDelete, Rate{Requests: 1, Period: time.Hour})
+
+	// host and method
+	limiter.reserve(testHost, http.MethodPost)
+	if delay := limiter.reserve(testHost, http.MethodPost); delay != 0 {
+		t.Errorf("Expected 2 POST to be allowed, got a delay of %v", delay)
+	}
+	if delay := limiter.reserve(testHost, http.MethodPost); delay != 30*time.Second {
+		t.Errorf("Expected the third POST to wait 30s but got %v", delay)
+	}
+
+	// the other methods of the host share the default budget
+	limiter.reserve(testHost, http.MethodGet)
+	if delay := limiter.reserve(testHost, http.MethodPatch); delay != time.Minute {
+		t.Errorf("Expected the PATCH to share the budget of the GET, got a delay of %v", delay)
+	}
+
+	// a rate for every host still has one budget per host
+	limiter.reserve(testHost, http.MethodDelete)
+	if delay := limiter.reserve("other.test", http.MethodDelete); delay != 0 {
+		t.Errorf("Expected the other host to have its own budget, got a delay of %v", delay)
+	}
+	if delay := limiter.reserve(testHost, http.MethodDelete); delay != time.Hour {
+		t.Errorf("Expected the DELETE to wait an hour but got %v", delay)
+	}
+}
+
+func TestRateLimiterFollowsServerBudget(t *testing.T) {
+	limiter, clock := newTestLimiter(Rate{})
+
+	response := &http.Response{Header: http.Header{}}
+	response.Header.Set(RateLimitLimitHeader, "100")
+	response.Header.Set(RateLimitRemainingHeader, "1")
+	response.Header.Set(RateLimitResetHeader, "5")
+	limiter.Update(testHost, http.MethodGet, response)
+
+	if delay := limiter.reserve(testHost, http.MethodGet); delay != 0 {
+		t.Fatalf("Expected the last request of the budget to be allowed, got a delay of %v", delay)
+	}
+	if delay := limiter.reserve(testHost, http.MethodGet); delay != 5*time.Second {
+		t.Errorf("Expected to wait for the reset but got %v", delay)
+	}
+
+	clock.Advance(5 * time.Second)
+	if delay := limiter.reserve(testHost, http.MethodGet); delay != 0 {
+		t.Errorf("Expected the budget to be refilled on reset, got a delay of %v", delay)
+	}
+}
+
+func TestRateLimiterServerBudgetIsShared(t *testing.T) {
+	limiter, clock := newTestLimiter(Rate{})
+	limiter.SetRate(testHost, http.MethodPost, Rate{Requests: 10, Period: time.Second})
+
+	response := &http.Response{Header: http.Header{}}
+	response.Header.Set(RateLimitRemainingHeader, "0")
+	response.Header.Set(RateLimitResetHeader, "3")
+	limiter.Update(testHost, http.MethodGet, response)
+
+	if delay := limiter.reserve(testHost, http.MethodPost); delay != 3*time.Second {
+		t.Errorf("Expected the POST to wait for the reset announced to the GET but got %v", delay)
+	}
+	if delay := limiter.reserve("other.test", http.MethodPost); delay != 0 {
+		t.Errorf("Expected the other host to keep its budget, got a delay of %v", delay)
+	}
+
+	clock.Advance(3 * time.Second)
+	if delay := limiter.reserve(testHost, http.MethodPost); delay != 0 {
+		t.Errorf("Expected the budget to be refilled on reset, got a delay of %v", delay)
+	}
+}
+
+func TestRateLimiterResetAsUnixTime(t *testing.T) {
+	limiter, _ := newTestLimiter(Rate{Requests: 10, Period: time.Second})
+
+	response := &http.Response{Header: http.Header{}}
+	response.Header.Set(RateLimitRemainingHeader, "0")
+	response.Header.Set(RateLimitResetHeader, strconv.FormatInt(limiter.clock().Add(2*time.Second).Unix(), 10))
+	limiter.Update(testHost, http.MethodGet, response)
+
+	if delay := limiter.reserve(testHost, http.MethodGet); delay != 2*time.Second {
+		t.Errorf("Expected to wait for the reset given as a unix time, got %v", delay)
+	}
+}
+
+func TestRateLimiterIgnoresIncompleteHeaders(t *testing.T) {
+	limiter, _ := newTestLimiter(Rate{})
+
+	for _, headers := range []map[string]string{
+		{RateLimitRemainingHeader: "0"},
+		{RateLimitResetHeader: "10"},
+		{RateLimitRemainingHeader: "none", RateLimitResetHeader: "10"},
+		{RateLimitRemainingHeader: "0", RateLimitResetHeader: "soon"},
+	} {
+		response := &http.Response{Header: http.Header{}}
+		for key, value := range headers {
+			response.Header.Set(key, value)
+		}
+		limiter.Update(testHost, http.MethodGet, response)
+
+		if delay := limiter.reserve(testHost, http.MethodGet); delay != 0 {
+			t.Errorf("Headers %v should be ignored, got a delay of %v", headers, delay)
+		}
+	}
+}
+
+func TestRateLimiterWaitHonoursContext(t *testing.T) {
+	limiter := NewRateLimiter(Rate{Requests: 1, Period: time.Hour})
+	limiter.Wait(context.Background(), testHost, http.MethodGet)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := limiter.Wait(ctx, testHost, http.MethodGet)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+}
+
+func TestClientIsRateLimited(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(200)
+	}))
+	defer func() { testServer.Close() }()
+
+	client := Client{
+		HTTPClient:  &http.Client{},
+		baseURL:     testServer.URL,
+		RateLimiter: NewRateLimiter(Rate{Requests: 20, Period: time.Second, Burst: 1}),
+	}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := client.Get(nil, nil); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected 5 requests at 20 per second to take at least 200ms, took %v", elapsed)
+	}
+}
+
+func TestClientWaitsForServerReset(t *testing.T) {
+	var mutex sync.Mutex
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		callCount = callCount + 1
+		mutex.Unlock()
+		res.Header().Set(RateLimitLimitHeader, "1")
+		res.Header().Set(RateLimitRemainingHeader, "0")
+		res.Header().Set(RateLimitResetHeader, "0.2")
+		res.WriteHeader(200)
+	}))
+	defer func() { testServer.Close() }()
+
+	client := Client{
+		HTTPClient:  &http.Client{},
+		baseURL:     testServer.URL,
+		RateLimiter: &RateLimiter{},
+	}
+
+	start := time.Now()
+	client.Get(nil, nil)
+	client.Get(nil, nil)
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected the second request to wait for the reset, took %v", elapsed)
+	}
+	if callCount != 2 {
+		t.Errorf("Expected 2 calls but got %v", callCount)
+	}
+}
+
+func TestRateLimiterConcurrentUse(t *testing.T) {
+	limiter := NewRateLimiter(Rate{Requests: 1000, Period: time.Second})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				limiter.Wait(context.Background(), testHost, http.MethodGet)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,226 @@
+package httpclient
+
+import (
+	"crypto/x509"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header marking a non idempotent request as safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Jitter is the strategy used to randomise the delay between two attempts
+type Jitter int
+
+const (
+	// NoJitter waits exactly the exponential back-off delay
+	NoJitter Jitter = iota
+	// FullJitter waits a random delay between zero and the exponential back-off delay
+	FullJitter
+	// DecorrelatedJitter waits a random delay between the base delay and three times the previous delay
+	DecorrelatedJitter
+)
+
+// RetryPolicy controls how a Client retries a request that failed.
+//
+// Requests are retried when the response has one of the RetryableStatusCodes
+// or, for idempotent requests only, when the round trip fails.
+// POST and PATCH requests are never retried unless they carry an Idempotency-Key header.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between two attempts.
+	// A Retry-After longer than MaxDelay stops the retries. Zero means no cap.
+	MaxDelay time.Duration
+
+	// Multiplier is applied to the delay after every retry. Defaults to 2 when not set.
+	Multiplier float64
+
+	// Jitter is the strategy used to randomise the delay
+	Jitter Jitter
+
+	// RetryableStatusCodes are the response status codes that are retried.
+	// Defaults to 429, 500, 502, 503 and 504 when empty.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy is used by clients that have no RetryPolicy set
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: maxRetries + 1,
+	BaseDelay:   750 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+	Multiplier:  1.5,
+	Jitter:      FullJitter,
+}
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryPolicy returns the policy configured on the client or the default one
+func (c *Client) retryPolicy() *RetryPolicy {
+	if c.RetryPolicy != nil {
+		return c.RetryPolicy
+	}
+	return &DefaultRetryPolicy
+}
+
+// retryReason returns why the attempt should be retried, or an empty string if it should not.
+// Exactly one of response and err is expected to be set.
+func (p *RetryPolicy) retryReason(request *http.Request, response *http.Response, err error) string {
+	if !isIdempotent(request) {
+		return ""
+	}
+
+	if err != nil {
+		if isPermanentError(err) {
+			return ""
+		}
+		return "transport error: " + errorWithoutQuery(err)
+	}
+
+	statusCodes := p.RetryableStatusCodes
+	if len(statusCodes) == 0 {
+		statusCodes = defaultRetryableStatusCodes
+	}
+	for _, statusCode := range statusCodes {
+		if response.StatusCode == statusCode {
+			return "status " + strconv.Itoa(response.StatusCode)
+		}
+	}
+
+	return ""
+}
+
+// backoff returns how long to wait before the next attempt.
+// attempt is the number of attempts done so far and previous the last delay waited.
+// It returns false when the server asked to wait longer than MaxDelay.
+func (p *RetryPolicy) backoff(attempt int, previous time.Duration, response *http.Response) (time.Duration, bool) {
+	if retryAfter, ok := parseRetryAfter(response); ok {
+		if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+			return 0, false
+		}
+		return retryAfter, true
+	}
+
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	var delay time.Duration
+	switch p.Jitter {
+	case DecorrelatedJitter:
+		upper := float64(previous) * 3
+		if upper < float64(p.BaseDelay) {
+			upper = float64(p.BaseDelay)
+		}
+		delay = p.BaseDelay + time.Duration(randomFloat(upper-float64(p.BaseDelay)))
+	default:
+		delay = time.Duration(float64(p.BaseDelay) * math.Pow(multiplier, float64(attempt-1)))
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if p.Jitter == FullJitter {
+		delay = time.Duration(randomFloat(float64(delay)))
+	}
+
+	return delay, true
+}
+
+// parseRetryAfter reads the Retry-After header of 429 and 503 responses.
+// The header can be either a number of seconds or an http date.
+func parseRetryAfter(response *http.Response) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+	if response.StatusCode != http.StatusTooManyRequests && response.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// isIdempotent reports whether sending the request twice has the same effect as sending it once
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return request.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// isPermanentError reports whether a transport error would happen again on a new attempt,
+// e.g. an invalid url, an unknown host or a certificate not trusted
+func isPermanentError(err error) bool {
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) && dnsError.IsNotFound {
+		return true
+	}
+
+	var unknownAuthorityError x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthorityError) {
+		return true
+	}
+
+	var certificateInvalidError x509.CertificateInvalidError
+	if errors.As(err, &certificateInvalidError) {
+		return true
+	}
+
+	var hostnameError x509.HostnameError
+	if errors.As(err, &hostnameError) {
+		return true
+	}
+
+	// the http package does not export a type for these errors
+	message := err.Error()
+	return strings.Contains(message, "unsupported protocol scheme") ||
+		strings.Contains(message, "no Host in request URL") ||
+		strings.Contains(message, "stopped after 10 redirects")
+}
+
+// randomFloat returns a random number in [0, max)
+func randomFloat(max float64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	return rand.Float64() * max
+}
@@ -0,0 +1,229 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoffWithoutJitter(t *testing.T) {
+	policy := RetryPolicy{
+		BaseDelay:  100 * time.Millisecond,
+		MaxDelay:   time.Second,
+		Multiplier: 2,
+		Jitter:     NoJitter,
+	}
+
+	expectedDelays := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+	}
+
+	for i, expectedDelay := range expectedDelays {
+		delay, retry := policy.backoff(i+1, 0, nil)
+		if !retry {
+			t.Errorf("backoff stopping the retries on attempt %v", i+1)
+		}
+		if delay != expectedDelay {
+			t.Errorf("backoff returning wrong delay on attempt %v: got %v expected %v", i+1, delay, expectedDelay)
+		}
+	}
+}
+
+func TestBackoffWithFullJitter(t *testing.T) {
+	policy := RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+		Jitter:    FullJitter,
+	}
+
+	for attempt := 1; attempt < 10; attempt++ {
+		delay, _ := policy.backoff(attempt, 0, nil)
+		if delay < 0 || delay > time.Second {
+			t.Errorf("backoff returning a delay out of bounds on attempt %v: got %v", attempt, delay)
+		}
+	}
+}
+
+func TestBackoffWithDecorrelatedJitter(t *testing.T) {
+	policy := RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+		Jitter:    DecorrelatedJitter,
+	}
+
+	var delay time.Duration
+	for attempt := 1; attempt < 10; attempt++ {
+		previous := delay
+		delay, _ = policy.backoff(attempt, previous, nil)
+		if delay < policy.BaseDelay || delay > policy.MaxDelay {
+			t.Errorf("backoff returning a delay out of bounds on attempt %v: got %v", attempt, delay)
+		}
+		if previous > 0 && delay > 3*previous {
+			t.Errorf("backoff returning a delay bigger than three times the previous one: got %v previous %v", delay, previous)
+		}
+	}
+}
+
+func TestBackoffHonoursRetryAfter(t *testing.T) {
+	policy := RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  10 * time.Second,
+		Jitter:    FullJitter,
+	}
+
+	response := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"3"}},
+	}
+	delay, retry := policy.backoff(1, 0, response)
+	if !retry || delay != 3*time.Second {
+		t.Errorf("backoff not honouring Retry-After in seconds: got %v expected %v", delay, 3*time.Second)
+	}
+
+	response = &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{time.Now().Add(5 * time.Second).UTC().Format(http.TimeFormat)}},
+	}
+	delay, retry = policy.backoff(1, 0, response)
+	if !retry || delay <= 3*time.Second || delay > 5*time.Second {
+		t.Errorf("backoff not honouring Retry-After as a date: got %v", delay)
+	}
+
+	response = &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"60"}},
+	}
+	_, retry = policy.backoff(1, 0, response)
+	if retry {
+		t.Errorf("backoff retrying when Retry-After is longer than the max delay")
+	}
+}
+
+func TestRetryReason(t *testing.T) {
+	policy := RetryPolicy{}
+	serverError := &http.Response{StatusCode: http.StatusInternalServerError}
+	notFound := &http.Response{StatusCode: http.StatusNotFound}
+	transportError := errors.New("connection reset by peer")
+
+	get, _ := http.NewRequest(http.MethodGet, testServerUrl, nil)
+	post, _ := http.NewRequest(http.MethodPost, testServerUrl, nil)
+	patch, _ := http.NewRequest(http.MethodPatch, testServerUrl, nil)
+	postWithKey, _ := http.NewRequest(http.MethodPost, testServerUrl, nil)
+	postWithKey.Header.Set(IdempotencyKeyHeader, "a6d5e3c2")
+
+	tests := []struct {
+		name        string
+		request     *http.Request
+		response    *http.Response
+		err         error
+		expectRetry bool
+	}{
+		{"get server error", get, serverError, nil, true},
+		{"get not found", get, notFound, nil, false},
+		{"get transport error", get, nil, transportError, true},
+		{"post server error", post, serverError, nil, false},
+		{"post transport error", post, nil, transportError, false},
+		{"patch server error", patch, serverError, nil, false},
+		{"post with idempotency key server error", postWithKey, serverError, nil, true},
+		{"post with idempotency key transport error", postWithKey, nil, transportError, true},
+		{"get unknown host", get, nil, &net.DNSError{Err: "no such host", Name: "api.test.com", IsNotFound: true}, false},
+	}
+
+	for _, test := range tests {
+		reason := policy.retryReason(test.request, test.response, test.err)
+		if (reason != "") != test.expectRetry {
+			t.Errorf("%v: got retry reason %q expected retry %v", test.name, reason, test.expectRetry)
+		}
+	}
+}
+
+func TestRetryCustomStatusCodes(t *testing.T) {
+	policy := RetryPolicy{RetryableStatusCodes: []int{http.StatusConflict}}
+	get, _ := http.NewRequest(http.MethodGet, testServerUrl, nil)
+
+	if policy.retryReason(get, &http.Response{StatusCode: http.StatusConflict}, nil) == "" {
+		t.Errorf("custom retryable status code not retried")
+	}
+	if policy.retryReason(get, &http.Response{StatusCode: http.StatusInternalServerError}, nil) != "" {
+		t.Errorf("status code not listed in the policy retried")
+	}
+}
+
+func TestGetRetryWhenTransportError(t *testing.T) {
+	callCount := 0
+
+	goClient := &MockClient{
+		MockedDo: func(req *http.Request) (*http.Response, error) {
+			callCount = callCount + 1
+			if callCount < 3 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString("body")),
+			}, nil
+		},
+	}
+
+	client := Client{
+		HTTPClient:  goClient,
+		baseURL:     testServerUrl,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 5},
+	}
+
+	res, err := client.Get(nil, nil)
+	if err != nil {
+		t.Errorf("request returning an error after retrying: got %v", err)
+	}
+	if string(res) != "body" {
+		t.Errorf("request returning a different response body: got %v expected %v", string(res), "body")
+	}
+	if callCount != 3 {
+		t.Errorf("Retrying policy not working as expected. Number of calls %v expected %v", callCount, 3)
+	}
+}
+
+func TestRetryWaitsBetweenAttempts(t *testing.T) {
+	callCount := 0
+
+	goClient := &MockClient{
+		MockedDo: func(req *http.Request) (*http.Response, error) {
+			callCount = callCount + 1
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Status:     "503 Service Unavailable",
+				Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+			}, nil
+		},
+	}
+
+	client := Client{
+		HTTPClient: goClient,
+		baseURL:    testServerUrl,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   20 * time.Millisecond,
+			Multiplier:  1,
+			Jitter:      NoJitter,
+		},
+	}
+
+	start := time.Now()
+	client.Get(nil, nil)
+	elapsed := time.Since(start)
+
+	if callCount != 3 {
+		t.Errorf("Retrying policy not working as expected. Number of calls %v expected %v", callCount, 3)
+	}
+	if elapsed < 40*time.Millisecond {
+		t.Errorf("Retrying policy not waiting between attempts: took %v expected at least %v", elapsed, 40*time.Millisecond)
+	}
+}
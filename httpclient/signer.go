@@ -0,0 +1,258 @@
+package httpclient
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Signer authenticates a request before it is sent.
+// Sign is called before every attempt, with the body of the request.
+type Signer interface {
+	Sign(request *http.Request, body []byte) error
+}
+
+// ErrInvalidSignature is returned, wrapped, by a Verifier refusing a request
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// signatureAlgorithm is the only algorithm supported by the API
+const signatureAlgorithm = "rsa-sha256"
+
+// DefaultSignatureHeaders are the headers signed by an HTTPSigner without Headers
+var DefaultSignatureHeaders = []string{"(request-target)", "host", "date", "digest"}
+
+// HTTPSigner signs the requests following the HTTP Signatures draft with an RSA-SHA256 key,
+// as required by the production API.
+//
+// It sets the Date header, the SHA-256 Digest of the body and the Authorization header
+// carrying the signature of the Headers.
+type HTTPSigner struct {
+	// KeyID identifies the public key the API uses to check the signature
+	KeyID string
+
+	// PrivateKey signs the requests
+	PrivateKey *rsa.PrivateKey
+
+	// Headers are the headers covered by the signature, in order. Defaults to DefaultSignatureHeaders.
+	Headers []string
+
+	now func() time.Time
+}
+
+// NewHTTPSigner returns an HTTPSigner signing the default headers with the key
+func NewHTTPSigner(keyID string, privateKey *rsa.PrivateKey) *HTTPSigner {
+	return &HTTPSigner{KeyID: keyID, PrivateKey: privateKey}
+}
+
+// Sign sets the Date, Digest and Authorization headers of the request
+func (s *HTTPSigner) Sign(request *http.Request, body []byte) error {
+	if s.PrivateKey == nil {
+		return errors.New("the signer has no private key")
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	request.Header.Set("Date", now().UTC().Format(http.TimeFormat))
+	request.Header.Set("Digest", digest(body))
+
+	headers := s.Headers
+	if len(headers) == 0 {
+		headers = DefaultSignatureHeaders
+	}
+
+	signingString, err := buildSigningString(request, headers)
+	if err != nil {
+		return err
+	}
+
+	hashed := sha256.Sum256([]byte(signingString))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.PrivateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", fmt.Sprintf(`Signature keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		s.KeyID, signatureAlgorithm, strings.ToLower(strings.Join(headers, " ")), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+// Verifier checks the signature of the requests signed by an HTTPSigner, e.g. in a test server
+type Verifier struct {
+	// Keys are the public keys by key id
+	Keys map[string]*rsa.PublicKey
+
+	// MaxSkew is the largest difference accepted between the Date header and the clock. Zero disables the check.
+	MaxSkew time.Duration
+}
+
+// Verify checks the Authorization header of the request against its headers and body.
+// The errors returned wrap ErrInvalidSignature.
+func (v *Verifier) Verify(request *http.Request, body []byte) error {
+	params, err := parseSignature(request.Header.Get("Authorization"))
+	if err != nil {
+		return err
+	}
+
+	publicKey, ok := v.Keys[params["keyId"]]
+	if !ok {
+		return fmt.Errorf("%w: unknown key id %q", ErrInvalidSignature, params["keyId"])
+	}
+	if params["algorithm"] != signatureAlgorithm {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, params["algorithm"])
+	}
+
+	headers := strings.Fields(params["headers"])
+	if len(headers) == 0 {
+		// the draft signs the date alone when the list is missing
+		headers = []string{"date"}
+	}
+
+	for _, header := range headers {
+		if header == "digest" && request.Header.Get("Digest") != digest(body) {
+			return fmt.Errorf("%w: the digest does not match the body", ErrInvalidSignature)
+		}
+	}
+
+	if v.MaxSkew > 0 {
+		date, err := http.ParseTime(request.Header.Get("Date"))
+		if err != nil {
+			return fmt.Errorf("%w: invalid date %q", ErrInvalidSignature, request.Header.Get("Date"))
+		}
+		if skew := time.Since(date); skew > v.MaxSkew || skew < -v.MaxSkew {
+			return fmt.Errorf("%w: the date is %v away from the clock", ErrInvalidSignature, skew)
+		}
+	}
+
+	signingString, err := buildSigningString(request, headers)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil {
+		return fmt.Errorf("%w: the signature is not base64", ErrInvalidSignature)
+	}
+
+	hashed := sha256.Sum256([]byte(signingString))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// buildSigningString builds the string signed, one "name: value" line per header
+func buildSigningString(request *http.Request, headers []string) (string, error) {
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		name := strings.ToLower(header)
+
+		var value string
+		switch name {
+		case "(request-target)":
+			value = strings.ToLower(request.Method) + " " + request.URL.RequestURI()
+		case "host":
+			value = request.Host
+			if value == "" {
+				value = request.URL.Host
+			}
+		default:
+			values := request.Header[http.CanonicalHeaderKey(name)]
+			if len(values) == 0 {
+				return "", fmt.Errorf("the header %v to sign is missing", name)
+			}
+			value = strings.Join(values, ", ")
+		}
+
+		lines[i] = name + ": " + value
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// parseSignature reads the parameters of an Authorization: Signature header
+func parseSignature(authorization string) (map[string]string, error) {
+	const prefix = "Signature "
+	if !strings.HasPrefix(authorization, prefix) {
+		return nil, fmt.Errorf("%w: missing Signature authorization", ErrInvalidSignature)
+	}
+
+	params := make(map[string]string)
+	for _, param := range strings.Split(strings.TrimPrefix(authorization, prefix), ",") {
+		equal := strings.Index(param, "=")
+		if equal < 0 {
+			return nil, fmt.Errorf("%w: malformed parameter %q", ErrInvalidSignature, param)
+		}
+		params[strings.TrimSpace(param[:equal])] = strings.Trim(strings.TrimSpace(param[equal+1:]), `"`)
+	}
+	return params, nil
+}
+
+// digest returns the value of the Digest header of the body
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// LoadPrivateKey reads an RSA private key from a PEM block, in PKCS #1 or PKCS #8 format
+func LoadPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in the private key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// LoadPrivateKeyFile reads an RSA private key from a PEM file
+func LoadPrivateKeyFile(path string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPrivateKey(data)
+}
+
+// LoadPublicKey reads an RSA public key from a PEM block, in PKIX or PKCS #1 format
+func LoadPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in the public key")
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("the public key is not an RSA key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,276 @@
+package httpclient
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testKeyID = "75a8ba12-fff2-4a52-ad8a-e8b34c5ccec8"
+
+var testKey *rsa.PrivateKey
+
+func init() {
+	var err error
+	testKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newTestVerifier() *Verifier {
+	return &Verifier{Keys: map[string]*rsa.PublicKey{testKeyID: &testKey.PublicKey}, MaxSkew: time.Minute}
+}
+
+func newSignedRequest(t *testing.T, method string, body string) *http.Request {
+	request := httptest.NewRequest(method, "http://api.test/v1/organisation/accounts?page%5Bnumber%5D=1", strings.NewReader(body))
+	request.Host = "api.form3.tech"
+
+	if err := NewHTTPSigner(testKeyID, testKey).Sign(request, []byte(body)); err != nil {
+		t.Fatalf("Unable to sign the request: %v", err)
+	}
+	return request
+}
+
+func TestSignSetsHeaders(t *testing.T) {
+	signer := NewHTTPSigner(testKeyID, testKey)
+	signer.now = func() time.Time { return time.Date(2021, 3, 1, 14, 30, 0, 0, time.FixedZone("CET", 3600)) }
+
+	request := httptest.NewRequest(http.MethodPost, "http://api.test/v1/organisation/accounts", nil)
+	if err := signer.Sign(request, []byte(`{"data":{}}`)); err != nil {
+		t.Fatalf("Unable to sign the request: %v", err)
+	}
+
+	if got := request.Header.Get("Date"); got != "Mon, 01 Mar 2021 13:30:00 GMT" {
+		t.Errorf("Expected an RFC 1123 date in GMT but got %q", got)
+	}
+	if got := request.Header.Get("Digest"); got != "SHA-256=f7nRZtGhW84LnwhfOBiUb9kpfkUTpKA0oM63SSkrTA0=" {
+		t.Errorf("Unexpected digest %q", got)
+	}
+
+	authorization := request.Header.Get("Authorization")
+	expectedPrefix := `Signature keyId="` + testKeyID + `",algorithm="rsa-sha256",headers="(request-target) host date digest",signature="`
+	if !strings.HasPrefix(authorization, expectedPrefix) {
+		t.Errorf("Unexpected authorization %q", authorization)
+	}
+}
+
+func TestSigningString(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "http://api.test/v1/organisation/accounts?page%5Bnumber%5D=1", nil)
+	request.Host = "api.form3.tech"
+	request.Header.Set("Date", "Mon, 01 Mar 2021 13:30:00 GMT")
+	request.Header.Set("Digest", digest(nil))
+
+	got, err := buildSigningString(request, DefaultSignatureHeaders)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	expected := "(request-target): get /v1/organisation/accounts?page%5Bnumber%5D=1\n" +
+		"host: api.form3.tech\n" +
+		"date: Mon, 01 Mar 2021 13:30:00 GMT\n" +
+		"digest: SHA-256=47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	if got != expected {
+		t.Errorf("Expected\n%v\nbut got\n%v", expected, got)
+	}
+
+	if _, err := buildSigningString(request, []string{"x-missing"}); err == nil {
+		t.Error("Expected an error signing a missing header")
+	}
+}
+
+func TestVerifySignedRequest(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+		body := ""
+		if method == http.MethodPost {
+			body = `{"data":{"type":"accounts"}}`
+		}
+		request := newSignedRequest(t, method, body)
+
+		if err := newTestVerifier().Verify(request, []byte(body)); err != nil {
+			t.Errorf("%v: expected the signature to be valid, got %v", method, err)
+		}
+	}
+}
+
+func TestVerifyRefusesTamperedRequests(t *testing.T) {
+	body := `{"data":{"type":"accounts"}}`
+
+	for name, tamper := range map[string]func(r *http.Request) []byte{
+		"body":   func(r *http.Request) []byte { return []byte(`{"data":{"type":"other"}}`) },
+		"digest": func(r *http.Request) []byte { r.Header.Set("Digest", digest([]byte("other"))); return []byte("other") },
+		"path":   func(r *http.Request) []byte { r.URL.Path = "/v1/organisation/other"; return []byte(body) },
+		"method": func(r *http.Request) []byte { r.Method = http.MethodPut; return []byte(body) },
+		"host":   func(r *http.Request) []byte { r.Host = "evil.test"; return []byte(body) },
+		"date": func(r *http.Request) []byte {
+			r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat)+" ")
+			return []byte(body)
+		},
+		"old date": func(r *http.Request) []byte {
+			r.Header.Set("Date", "Mon, 01 Mar 2021 13:30:00 GMT")
+			return []byte(body)
+		},
+		"key id": func(r *http.Request) []byte { setAuthorizationParam(r, "keyId", "other"); return []byte(body) },
+		"algorithm": func(r *http.Request) []byte {
+			setAuthorizationParam(r, "algorithm", "hmac-sha256")
+			return []byte(body)
+		},
+		"no auth": func(r *http.Request) []byte { r.Header.Del("Authorization"); return []byte(body) },
+	} {
+		request := newSignedRequest(t, http.MethodPost, body)
+		tamperedBody := tamper(request)
+
+		err := newTestVerifier().Verify(request, tamperedBody)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%v: expected ErrInvalidSignature but got %v", name, err)
+		}
+	}
+}
+
+// setAuthorizationParam replaces a parameter of the Authorization header
+func setAuthorizationParam(request *http.Request, name string, value string) {
+	params := strings.Split(strings.TrimPrefix(request.Header.Get("Authorization"), "Signature "), ",")
+	for i, param := range params {
+		if strings.HasPrefix(param, name+"=") {
+			params[i] = name + `="` + value + `"`
+		}
+	}
+	request.Header.Set("Authorization", "Signature "+strings.Join(params, ","))
+}
+
+func TestLoadKeys(t *testing.T) {
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkix, err := x509.MarshalPKIXPublicKey(&testKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{
+		"PKCS #1": pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(testKey)}),
+		"PKCS #8": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+	} {
+		key, err := LoadPrivateKey(data)
+		if err != nil || !key.Equal(testKey) {
+			t.Errorf("%v: unable to load the private key: %v", name, err)
+		}
+	}
+
+	for name, data := range map[string][]byte{
+		"PKCS #1": pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&testKey.PublicKey)}),
+		"PKIX":    pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}),
+	} {
+		key, err := LoadPublicKey(data)
+		if err != nil || !key.Equal(&testKey.PublicKey) {
+			t.Errorf("%v: unable to load the public key: %v", name, err)
+		}
+	}
+
+	if _, err := LoadPrivateKey([]byte("not a key")); err == nil {
+		t.Error("Expected an error loading an invalid key")
+	}
+	if _, err := LoadPrivateKeyFile("testdata/missing.pem"); err == nil {
+		t.Error("Expected an error loading a missing file")
+	}
+}
+
+func TestClientSignsEveryAttempt(t *testing.T) {
+	var mutex sync.Mutex
+	var signatures []string
+	var verifyErrors []error
+
+	verifier := newTestVerifier()
+	handler := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err := verifier.Verify(req, body); err != nil {
+			verifyErrors = append(verifyErrors, err)
+		}
+		signatures = append(signatures, req.Header.Get("Authorization"))
+		res.WriteHeader(201)
+	})
+	scripted := &scriptedServer{script: []http.HandlerFunc{errorStatus(503), handler}}
+	testServer := httptest.NewServer(scripted)
+	defer func() { testServer.Close() }()
+
+	client := Client{
+		HTTPClient:  &http.Client{},
+		baseURL:     testServer.URL + "/v1/organisation/accounts",
+		RetryPolicy: noDelayRetryPolicy(),
+		Signer:      NewHTTPSigner(testKeyID, testKey),
+	}
+
+	// the 503 answers before the handler, only the retry reaches the verifier
+	_, err := client.Post(map[string]string{"Host": "api.form3.tech", IdempotencyKeyHeader: "key"}, []byte(`{"data":{}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if scripted.Requests() != 2 {
+		t.Errorf("Expected 2 attempts but got %v", scripted.Requests())
+	}
+	if len(verifyErrors) > 0 || len(signatures) != 1 {
+		t.Errorf("Expected the retry to be signed, got %v %v", signatures, verifyErrors)
+	}
+}
+
+func TestClientReturnsSigningError(t *testing.T) {
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		callCount = callCount + 1
+	}))
+	defer func() { testServer.Close() }()
+
+	client := Client{
+		HTTPClient: &http.Client{},
+		baseURL:    testServer.URL,
+		Signer:     &HTTPSigner{KeyID: testKeyID},
+	}
+
+	if _, err := client.Get(nil, nil); err == nil {
+		t.Error("Expected the signing error to be returned")
+	}
+	if callCount != 0 {
+		t.Errorf("An unsigned request was sent")
+	}
+}
+
+func TestClientSignsTheHeadersOfTheMiddlewares(t *testing.T) {
+	verifier := newTestVerifier()
+	verifyErr := errors.New("no request received")
+	var userAgent string
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		verifyErr = verifier.Verify(req, nil)
+		userAgent = req.Header.Get("User-Agent")
+		res.WriteHeader(204)
+	}))
+	defer func() { testServer.Close() }()
+
+	signer := NewHTTPSigner(testKeyID, testKey)
+	signer.Headers = []string{"(request-target)", "host", "date", "digest", "x-request-id", "user-agent"}
+	client := Client{
+		HTTPClient: &http.Client{},
+		baseURL:    testServer.URL,
+		Signer:     signer,
+	}
+	client.Use(RequestID(), Headers(map[string]string{"User-Agent": "form3-client-test"}))
+
+	if _, err := client.Get(nil, nil); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if verifyErr != nil || userAgent != "form3-client-test" {
+		t.Errorf("Expected the headers of the middlewares to be signed, got %v with User-Agent %q", verifyErr, userAgent)
+	}
+}
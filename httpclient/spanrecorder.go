@@ -0,0 +1,118 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// SpanRecorder is a Tracer keeping the spans in memory, e.g. to check them in tests.
+// It is safe for concurrent use.
+type SpanRecorder struct {
+	mutex sync.Mutex
+	spans []*RecordedSpan
+}
+
+// RecordedSpan is a span started by a SpanRecorder
+type RecordedSpan struct {
+	Name        string
+	SpanContext SpanContext
+	// Parent is the span context of the parent span, invalid for a root span
+	Parent     SpanContext
+	Attributes map[string]interface{}
+	Errors     []error
+	Start      time.Time
+	End        time.Time
+	Ended      bool
+}
+
+// NewSpanRecorder returns an empty SpanRecorder
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+// Start implements Tracer. The span is the child of the span of ctx, in the same trace.
+func (r *SpanRecorder) Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span) {
+	recorded := &RecordedSpan{Name: name, Attributes: make(map[string]interface{}), Start: time.Now()}
+	recorded.SpanContext.Sampled = true
+	rand.Read(recorded.SpanContext.SpanID[:])
+
+	if parent := SpanFromContext(ctx); parent != nil && parent.SpanContext().IsValid() {
+		recorded.Parent = parent.SpanContext()
+		recorded.SpanContext.TraceID = recorded.Parent.TraceID
+	} else {
+		rand.Read(recorded.SpanContext.TraceID[:])
+	}
+	for _, attribute := range attributes {
+		recorded.Attributes[attribute.Key] = attribute.Value
+	}
+
+	r.mutex.Lock()
+	r.spans = append(r.spans, recorded)
+	r.mutex.Unlock()
+
+	span := &recordingSpan{recorder: r, span: recorded}
+	return ContextWithSpan(ctx, span), span
+}
+
+// Spans returns a copy of the spans started, in the order they were started
+func (r *SpanRecorder) Spans() []RecordedSpan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	spans := make([]RecordedSpan, len(r.spans))
+	for i, span := range r.spans {
+		spans[i] = *span
+		spans[i].Attributes = make(map[string]interface{}, len(span.Attributes))
+		for key, value := range span.Attributes {
+			spans[i].Attributes[key] = value
+		}
+		spans[i].Errors = append([]error(nil), span.Errors...)
+	}
+	return spans
+}
+
+// Reset drops the spans recorded so far
+func (r *SpanRecorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.spans = nil
+}
+
+// recordingSpan is the Span of a SpanRecorder, its changes are made under the lock of the recorder
+type recordingSpan struct {
+	recorder *SpanRecorder
+	span     *RecordedSpan
+}
+
+func (s *recordingSpan) SetAttributes(attributes ...Attribute) {
+	s.recorder.mutex.Lock()
+	defer s.recorder.mutex.Unlock()
+
+	for _, attribute := range attributes {
+		s.span.Attributes[attribute.Key] = attribute.Value
+	}
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.recorder.mutex.Lock()
+	defer s.recorder.mutex.Unlock()
+
+	s.span.Errors = append(s.span.Errors, err)
+}
+
+func (s *recordingSpan) End() {
+	s.recorder.mutex.Lock()
+	defer s.recorder.mutex.Unlock()
+
+	if !s.span.Ended {
+		s.span.Ended = true
+		s.span.End = time.Now()
+	}
+}
+
+func (s *recordingSpan) SpanContext() SpanContext {
+	return s.span.SpanContext
+}
@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptedServer answers the first requests with the steps of its script, in order, and the next ones with accounts
+type scriptedServer struct {
+	mutex    sync.Mutex
+	script   []http.HandlerFunc
+	requests int
+}
+
+func (s *scriptedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests = s.requests + 1
+	step := http.HandlerFunc(accounts)
+	if len(s.script) > 0 {
+		step, s.script = s.script[0], s.script[1:]
+	}
+	s.mutex.Unlock()
+
+	step(w, r)
+}
+
+// Requests returns the number of requests received
+func (s *scriptedServer) Requests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests
+}
+
+// newScriptedClient returns a client calling a server answering with the script before succeeding
+func newScriptedClient(t *testing.T, policy RetryPolicy, script ...http.HandlerFunc) (*Client, *scriptedServer) {
+	scripted := &scriptedServer{script: script}
+	server := httptest.NewServer(scripted)
+	t.Cleanup(server.Close)
+
+	client := &Client{
+		baseURL:     server.URL + "/v1/organisation/accounts",
+		HTTPClient:  &http.Client{},
+		RetryPolicy: &policy,
+	}
+	return client, scripted
+}
+
+// accounts answers a POST with the account sent and the other requests with an empty list
+func accounts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if r.Method == http.MethodPost {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+		return
+	}
+	w.Write([]byte(`{"data":[]}`))
+}
+
+// errorStatus answers with an error of the status code
+func errorStatus(statusCode int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		fmt.Fprintf(w, `{"error_message":%q}`, http.StatusText(statusCode))
+	}
+}
+
+// connectionReset closes the connection without answering
+func connectionReset(w http.ResponseWriter, r *http.Request) {
+	if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+		conn.Close()
+	}
+}
+
+// truncatedBody announces a longer body than the one sent, then closes the connection
+func truncatedBody(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Length", "64")
+	w.Write([]byte(`{"data":[`))
+	w.(http.Flusher).Flush()
+	if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+		conn.Close()
+	}
+}
+
+// testTokenPath is the token endpoint of the tokenServer
+const testTokenPath = "/oauth2/token"
+
+// tokenServer issues tokens to the client "client" with the secret "s3cret" and refuses the other requests without one
+type tokenServer struct {
+	mutex  sync.Mutex
+	tokens map[string]bool
+	issued int
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r.URL.Path == testTokenPath {
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "client" || clientSecret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		s.issued = s.issued + 1
+		token := strconv.Itoa(s.issued)
+		s.tokens[token] = true
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600}`, token)
+		return
+	}
+
+	if !s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	accounts(w, r)
+}
+
+// Issued returns the number of tokens issued
+func (s *tokenServer) Issued() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.issued
+}
+
+// RevokeAll invalidates every token issued
+func (s *tokenServer) RevokeAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens = make(map[string]bool)
+}
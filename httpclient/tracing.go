@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TraceParentHeader is the W3C Trace Context header propagating the span of a request to the API
+const TraceParentHeader = "traceparent"
+
+// ErrInvalidTraceParent is returned when a traceparent header can not be parsed
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// Tracer starts spans. It follows the OpenTelemetry tracing API,
+// an adapter to an OpenTelemetry tracer only has to convert the attributes and the span context.
+type Tracer interface {
+	// Start starts a span, child of the span of ctx if any, and returns a context holding it
+	Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span)
+}
+
+// Span is an operation traced by a Tracer
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+	SpanContext() SpanContext
+}
+
+// Attribute is a key value pair describing a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Attr returns an Attribute
+func Attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanContext identifies a span across processes
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether the trace and span IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent returns the value of the traceparent header propagating the span
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceParent reads the span context of a traceparent header
+func ParseTraceParent(header string) (SpanContext, error) {
+	var sc SpanContext
+
+	// the fields of the future versions come after the ones of version 00
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, ErrInvalidTraceParent
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, ErrInvalidTraceParent
+	}
+
+	var version, flags [1]byte
+	if _, err := hex.Decode(version[:], []byte(parts[0])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, ErrInvalidTraceParent
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceParent
+	}
+
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context holding the span, for the implementations of Tracer
+func ContextWithSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span of the context, nil if there is none
+func SpanFromContext(ctx context.Context) Span {
+	span, _ := ctx.Value(spanKey{}).(Span)
+	return span
+}
+
+// startAttemptSpan starts the span of an attempt of the request and propagates it to the API
+func (c *Client) startAttemptSpan(ctx context.Context, request *http.Request, attempt int) Span {
+	if c.Tracer == nil {
+		return nil
+	}
+
+	attributes := []Attribute{
+		Attr("http.request.method", request.Method),
+		Attr("server.address", request.URL.Host),
+		Attr("url.path", request.URL.Path),
+		Attr("http.request.attempt", attempt),
+	}
+	if operation := OperationFromContext(ctx); operation != "" {
+		attributes = append(attributes, Attr("operation", operation))
+	}
+
+	_, span := c.Tracer.Start(ctx, "HTTP "+request.Method, attributes...)
+	if sc := span.SpanContext(); sc.IsValid() {
+		request.Header.Set(TraceParentHeader, sc.TraceParent())
+	}
+	return span
+}
+
+// endAttemptSpan records the outcome of the attempt and ends its span
+func endAttemptSpan(span Span, entry attemptLog) {
+	if span == nil {
+		return
+	}
+
+	if entry.response != nil {
+		span.SetAttributes(Attr("http.response.status_code", entry.response.StatusCode))
+	}
+	if entry.retryReason != "" {
+		span.SetAttributes(Attr("retry.reason", entry.retryReason))
+	}
+	if entry.err != nil {
+		span.RecordError(entry.err)
+	}
+	span.End()
+}
@@ -0,0 +1,108 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestTracerSpansEveryAttempt(t *testing.T) {
+	var mutex sync.Mutex
+	var traceParents []string
+	callCount := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		traceParents = append(traceParents, req.Header.Get(TraceParentHeader))
+		callCount = callCount + 1
+		if callCount == 1 {
+			res.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		res.Write([]byte(`{"data":[]}`))
+	}))
+	defer func() { testServer.Close() }()
+
+	recorder := NewSpanRecorder()
+	client := &Client{baseURL: testServer.URL + "/v1/organisation/accounts", HTTPClient: &http.Client{}, RetryPolicy: noDelayRetryPolicy(), Tracer: recorder}
+
+	ctx, parent := recorder.Start(WithOperation(context.Background(), "list"), "parent")
+	if _, err := client.GetWithContext(ctx, nil, nil); err != nil {
+		t.Fatalf("Request is returning an error: %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected the parent and 2 attempt spans, got %v", spans)
+	}
+	for i, span := range spans[1:] {
+		attempt := i + 1
+		if span.Name != "HTTP GET" || !span.Ended || span.Attributes["http.request.attempt"] != attempt {
+			t.Errorf("Unexpected span of attempt %v: %+v", attempt, span)
+		}
+		if span.Parent != parent.SpanContext() || span.SpanContext.TraceID != parent.SpanContext().TraceID {
+			t.Errorf("Expected the span of attempt %v to be a child of the parent span", attempt)
+		}
+		if traceParents[i] != span.SpanContext.TraceParent() {
+			t.Errorf("Expected attempt %v to propagate %v, got %v", attempt, span.SpanContext.TraceParent(), traceParents[i])
+		}
+		if span.Attributes["url.path"] != "/v1/organisation/accounts" || span.Attributes["operation"] != "list" {
+			t.Errorf("Unexpected attributes of attempt %v: %v", attempt, span.Attributes)
+		}
+	}
+	if spans[1].Attributes["http.response.status_code"] != 503 || spans[1].Attributes["retry.reason"] != "status 503" {
+		t.Errorf("Expected the first attempt to be retried after a 503, got %v", spans[1].Attributes)
+	}
+	if spans[2].Attributes["http.response.status_code"] != 200 || spans[2].Attributes["retry.reason"] != nil {
+		t.Errorf("Expected the second attempt to succeed, got %v", spans[2].Attributes)
+	}
+}
+
+func TestTracerRecordsTransportErrors(t *testing.T) {
+	client, _ := newScriptedClient(t, RetryPolicy{MaxAttempts: 1}, connectionReset)
+	recorder := NewSpanRecorder()
+	client.Tracer = recorder
+
+	client.Get(nil, nil)
+
+	spans := recorder.Spans()
+	if len(spans) != 1 || len(spans[0].Errors) != 1 || !spans[0].Ended {
+		t.Fatalf("Expected the span to record the error, got %+v", spans)
+	}
+	if spans[0].Parent.IsValid() {
+		t.Errorf("Expected a root span without parent span, got %+v", spans[0].Parent)
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceParent(header)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !sc.Sampled || sc.TraceParent() != header {
+		t.Errorf("Expected %v but got %v", header, sc.TraceParent())
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e47361-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceParent(invalid); err != ErrInvalidTraceParent {
+			t.Errorf("Expected %q to be invalid, got %v", invalid, err)
+		}
+	}
+
+	if _, err := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil {
+		t.Errorf("Expected the fields of a future version to be ignored, got %v", err)
+	}
+}
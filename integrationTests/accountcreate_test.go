@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"form3-interview/account"
+	"form3-interview/httpclient"
 	"form3-interview/models"
 
 	"github.com/google/uuid"
@@ -85,7 +86,7 @@ func TestCreateAccountReturnsBadRequest(t *testing.T) {
 		t.Errorf("Received unexpected response")
 	}
 
-	if err != nil && err.Error() != "400 Bad Request" {
+	if err != nil && !httpclient.IsBadRequest(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "400 Bad Request")
 	}
 }
@@ -115,7 +116,7 @@ func TestCreateAccountInvalidCoreAttributesReturnsError(t *testing.T) {
 		t.Errorf("Invalid ID should return error")
 	}
 
-	if err != nil && err.Error() != "500 Internal Server Error" {
+	if err != nil && !httpclient.IsServerError(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "500 Internal Server Error")
 	}
 
@@ -126,7 +127,7 @@ func TestCreateAccountInvalidCoreAttributesReturnsError(t *testing.T) {
 		t.Errorf("Invalid Organisation ID should return error")
 	}
 
-	if err != nil && err.Error() != "500 Internal Server Error" {
+	if err != nil && !httpclient.IsServerError(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "500 Internal Server Error")
 	}
 }
@@ -161,7 +162,7 @@ func TestCreateAccountInvalidAttributesReturnsError(t *testing.T) {
 		t.Errorf("Invalid Country should return error")
 	}
 
-	if err != nil && err.Error() != "400 Bad Request" {
+	if err != nil && !httpclient.IsBadRequest(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "400 Bad Request")
 	}
 
@@ -174,7 +175,7 @@ func TestCreateAccountInvalidAttributesReturnsError(t *testing.T) {
 		t.Errorf("Invalid Base Currency should return error")
 	}
 
-	if err != nil && err.Error() != "400 Bad Request" {
+	if err != nil && !httpclient.IsBadRequest(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "400 Bad Request")
 	}
 
@@ -187,7 +188,7 @@ func TestCreateAccountInvalidAttributesReturnsError(t *testing.T) {
 		t.Errorf("Invalid Bic should return error")
 	}
 
-	if err != nil && err.Error() != "400 Bad Request" {
+	if err != nil && !httpclient.IsBadRequest(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "400 Bad Request")
 	}
 
@@ -200,7 +201,7 @@ func TestCreateAccountInvalidAttributesReturnsError(t *testing.T) {
 		t.Errorf("Invalid Account Classification should return error")
 	}
 
-	if err != nil && err.Error() != "400 Bad Request" {
+	if err != nil && !httpclient.IsBadRequest(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "400 Bad Request")
 	}
 }
@@ -245,7 +246,7 @@ func TestCreateAccountAlreadyExistReturnsConflictError(t *testing.T) {
 		t.Errorf("Existing Account ID should return error")
 	}
 
-	if err != nil && err.Error() != "409 Conflict" {
+	if err != nil && !httpclient.IsConflict(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "409 Conflict")
 	}
 }
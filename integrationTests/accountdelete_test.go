@@ -2,6 +2,7 @@ package integration
 
 import (
 	"form3-interview/account"
+	"form3-interview/httpclient"
 	"os"
 	"testing"
 
@@ -35,7 +36,7 @@ func TestDeleteAccountReturnsAccountNotFound(t *testing.T) {
 
 	err := account.DeleteAccount(serverURL, &req)
 
-	if err != nil && err.Error() != "404 Not Found" {
+	if err != nil && !httpclient.IsNotFound(err) {
 		t.Errorf("Request is returning an unexpected error: got %v expected %v", err.Error(), "404 Not Found")
 	}
 }
@@ -51,7 +52,7 @@ func TestDeleteAccountReturnsConflict(t *testing.T) {
 
 	err := account.DeleteAccount(serverURL, &req)
 
-	if err != nil && err.Error() != "409 Conflict" {
+	if err != nil && !httpclient.IsConflict(err) {
 		t.Errorf("Request is returning an unexpected error: got %v expected %v", err.Error(), "409 Conflict")
 	}
 }
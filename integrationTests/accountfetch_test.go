@@ -2,6 +2,7 @@ package integration
 
 import (
 	"form3-interview/account"
+	"form3-interview/httpclient"
 	"form3-interview/models"
 	"os"
 	"testing"
@@ -62,7 +63,7 @@ func TestFetchAccountReturnsAccountNotFound(t *testing.T) {
 		t.Errorf("Request is returning an unexpected response")
 	}
 
-	if err != nil && err.Error() != "404 Not Found" {
+	if err != nil && !httpclient.IsNotFound(err) {
 		t.Errorf("Received unexpected error: %v expected %v", err.Error(), "404 Not Found")
 	}
 }
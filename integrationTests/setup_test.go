@@ -2,6 +2,7 @@ package integration
 
 import (
 	"form3-interview/account"
+	"form3-interview/httpclient"
 	"form3-interview/models"
 	"os"
 
@@ -59,7 +60,7 @@ func init() {
 	req.Data = &newData
 
 	_, err := account.CreateAccount(serverURL, &req)
-	if err != nil && err.Error() != "409 Conflict" {
+	if err != nil && !httpclient.IsConflict(err) {
 		panic(err)
 	}
 
@@ -67,7 +68,7 @@ func init() {
 	expectedAccount.ID, _ = uuid.Parse(deleteAccountID)
 
 	_, err = account.CreateAccount(serverURL, &req)
-	if err != nil && err.Error() != "409 Conflict" {
+	if err != nil && !httpclient.IsConflict(err) {
 		panic(err)
 	}
 
@@ -76,7 +77,7 @@ func init() {
 	for i < 10 {
 		expectedAccount.ID, _ = uuid.Parse(listAccountIDs[i])
 		_, err = account.CreateAccount(serverURL, &req)
-		if err != nil && err.Error() != "409 Conflict" {
+		if err != nil && !httpclient.IsConflict(err) {
 			panic(err)
 		}
 		i = i + 1
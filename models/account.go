@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -19,4 +21,33 @@ type Account struct {
 
 	// The specific attributes for each type of resource
 	Attributes *AccountAttributes `json:"attributes"`
+
+	// The date and time the resource was created, set by the API
+	CreatedOn *time.Time `json:"created_on,omitempty"`
+
+	// The date and time the resource was last modified, set by the API
+	ModifiedOn *time.Time `json:"modified_on,omitempty"`
+
+	// The resources related to the account
+	Relationships *AccountRelationships `json:"relationships,omitempty"`
+}
+
+// AccountRelationships contains the resources related to an account
+type AccountRelationships struct {
+	// The Account Events of the account, the newest one gives the status of the account
+	AccountEvents *Relationship `json:"account_events,omitempty"`
+}
+
+// Relationship contains the identifiers of the related resources
+type Relationship struct {
+	Data []ResourceIdentifier `json:"data"`
+}
+
+// ResourceIdentifier identifies a resource by its type and ID
+type ResourceIdentifier struct {
+	// The type of resource
+	Type string `json:"type"`
+
+	// The unique ID of the resource in UUID 4 format
+	ID uuid.UUID `json:"id"`
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccountMetadataRoundTrip(t *testing.T) {
+	body := `{
+		"type": "accounts",
+		"id": "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc",
+		"organisation_id": "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c",
+		"version": 1,
+		"created_on": "2021-05-06T09:28:13.843Z",
+		"modified_on": "2021-05-07T10:00:00Z",
+		"attributes": {"country": "GB"},
+		"relationships": {
+			"account_events": {
+				"data": [{"type": "account_events", "id": "c1023677-70ee-417a-9a6a-e211241f1e9c"}]
+			}
+		}
+	}`
+
+	var account Account
+	if err := json.Unmarshal([]byte(body), &account); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if account.CreatedOn == nil || !account.CreatedOn.Equal(time.Date(2021, 5, 6, 9, 28, 13, 843000000, time.UTC)) {
+		t.Errorf("Wrong created_on, got %v", account.CreatedOn)
+	}
+	if account.ModifiedOn == nil || !account.ModifiedOn.Equal(time.Date(2021, 5, 7, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong modified_on, got %v", account.ModifiedOn)
+	}
+	if account.Relationships == nil || account.Relationships.AccountEvents == nil || len(account.Relationships.AccountEvents.Data) != 1 {
+		t.Fatalf("Account events not decoded, got %+v", account.Relationships)
+	}
+	event := account.Relationships.AccountEvents.Data[0]
+	if event.Type != "account_events" || event.ID.String() != "c1023677-70ee-417a-9a6a-e211241f1e9c" {
+		t.Errorf("Wrong account event, got %+v", event)
+	}
+
+	encoded, _ := json.Marshal(account)
+	for _, field := range []string{`"created_on":"2021-05-06T09:28:13.843Z"`, `"modified_on":"2021-05-07T10:00:00Z"`, `"account_events":{"data":[{`} {
+		if !strings.Contains(string(encoded), field) {
+			t.Errorf("%v missing from %s", field, encoded)
+		}
+	}
+}
+
+func TestAccountWithoutMetadata(t *testing.T) {
+	encoded, _ := json.Marshal(Account{Type: "accounts"})
+	for _, field := range []string{"created_on", "modified_on", "relationships"} {
+		if strings.Contains(string(encoded), field) {
+			t.Errorf("%v must not be sent when not set: %s", field, encoded)
+		}
+	}
+}
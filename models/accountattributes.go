@@ -20,19 +20,31 @@ type AccountAttributes struct {
 	Bic string `json:"bic,omitempty"`
 
 	// IBAN of the account. Will be calculated from other fields if not supplied.
-	Iban string `json:"confirmed,omitempty"`
+	Iban string `json:"iban,omitempty"`
 
 	// A free-format reference that can be used to link this account to an external system
 	CustomerID string `json:"customer_id,omitempty"`
 
+	// Name of the account holder, up to four lines
+	Name []string `json:"name,omitempty"`
+
+	// Alternative primary account names, only used for Confirmation of Payee (CoP)
+	AlternativeNames []string `json:"alternative_names,omitempty"`
+
 	// The account holder's first name
+	//
+	// Deprecated: use Name
 	FirstName string `json:"first_name,omitempty"`
 
-	// Primary account name,
+	// Primary account name
+	//
+	// Deprecated: use Name
 	BankAccountName string `json:"bank_account_name,omitempty"`
 
 	// Alternative primary account names
-	AlternativeBankAccountNames []string `json:"alternative_bank_account_names"`
+	//
+	// Deprecated: use AlternativeNames
+	AlternativeBankAccountNames []string `json:"alternative_bank_account_names,omitempty"`
 
 	// Classification of account, only used for Confirmation of Payee (CoP)
 	AccountClassification string `json:"account_classification,omitempty"`
@@ -51,4 +63,106 @@ type AccountAttributes struct {
 
 	// Additional information to identify the account and account holder, only used for Confirmation of Payee (CoP)
 	SecondaryIdentification string `json:"secondary_identification,omitempty"`
+
+	// Name of the service processing the payments of the account, e.g. 'ABC Bank'
+	ProcessingService string `json:"processing_service,omitempty"`
+
+	// Free-format information about the account, e.g. for the processing service
+	UserDefinedInformation string `json:"user_defined_information,omitempty"`
+
+	// Type of validation the account is subject to, e.g. 'card'
+	ValidationType string `json:"validation_type,omitempty"`
+
+	// Mask the references of the payments to the account must match
+	ReferenceMask string `json:"reference_mask,omitempty"`
+
+	// Payments accepted by the account, e.g. 'same_day'
+	AcceptanceQualifier string `json:"acceptance_qualifier,omitempty"`
+
+	// Whether the account takes part in name matching: 'supported', 'switched', 'opted_out' or 'not_supported', only used for Confirmation of Payee (CoP)
+	NameMatchingStatus string `json:"name_matching_status,omitempty"`
+
+	// Identification of an account holder who is a person, only used for SEPA Indirect
+	PrivateIdentification *PrivateIdentification `json:"private_identification,omitempty"`
+
+	// Identification of an account holder who is an organisation, only used for SEPA Indirect
+	OrganisationIdentification *OrganisationIdentification `json:"organisation_identification,omitempty"`
+
+	// Key-value pairs stored with the account
+	UserDefinedData []UserDefinedData `json:"user_defined_data,omitempty"`
+}
+
+// PrivateIdentification identifies an account holder who is a person
+type PrivateIdentification struct {
+	// Date of birth in the YYYY-MM-DD format
+	BirthDate string `json:"birth_date,omitempty"`
+
+	// ISO 3166-1 code of the country of birth
+	BirthCountry string `json:"birth_country,omitempty"`
+
+	// Unique identification of the person, e.g. a passport or social security number
+	Identification string `json:"identification,omitempty"`
+
+	// Street name and house number, up to three lines
+	Address []string `json:"address,omitempty"`
+
+	// City of the address
+	City string `json:"city,omitempty"`
+
+	// ISO 3166-1 code of the country of the address
+	Country string `json:"country,omitempty"`
+}
+
+// OrganisationIdentification identifies an account holder who is an organisation
+type OrganisationIdentification struct {
+	// Unique identification of the organisation, e.g. a registration number
+	Identification string `json:"identification,omitempty"`
+
+	// People acting on behalf of the organisation
+	Actors []OrganisationActor `json:"actors,omitempty"`
+
+	// Street name and house number, up to three lines
+	Address []string `json:"address,omitempty"`
+
+	// City of the address
+	City string `json:"city,omitempty"`
+
+	// ISO 3166-1 code of the country of the address
+	Country string `json:"country,omitempty"`
+}
+
+// OrganisationActor is a person acting on behalf of an organisation
+type OrganisationActor struct {
+	// Name of the person, up to four lines
+	Name []string `json:"name,omitempty"`
+
+	// Date of birth in the YYYY-MM-DD format
+	BirthDate string `json:"birth_date,omitempty"`
+
+	// ISO 3166-1 code of the country of residence
+	Residency string `json:"residency,omitempty"`
+}
+
+// UserDefinedData is a key-value pair stored with an account
+type UserDefinedData struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// PersonalDataFields are the JSON names of the attributes identifying the account holder or the account.
+// Their values must never be logged.
+var PersonalDataFields = []string{
+	"account_number",
+	"iban",
+	"customer_id",
+	"name",
+	"alternative_names",
+	"first_name",
+	"bank_account_name",
+	"alternative_bank_account_names",
+	"secondary_identification",
+	"user_defined_information",
+	"private_identification",
+	"organisation_identification",
+	"user_defined_data",
 }
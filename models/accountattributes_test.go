@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAccountAttributesIbanRoundTrip(t *testing.T) {
+	attributes := AccountAttributes{Country: "GB", Iban: "GB16NWBK40030041426819"}
+
+	body, err := json.Marshal(attributes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(body), `"iban":"GB16NWBK40030041426819"`) {
+		t.Errorf("IBAN not serialised as iban: %s", body)
+	}
+
+	var decoded AccountAttributes
+	json.Unmarshal(body, &decoded)
+	if decoded.Iban != attributes.Iban {
+		t.Errorf("IBAN lost in the round trip, got %v", decoded.Iban)
+	}
+}
+
+func TestAccountAttributesOmitUnsetFields(t *testing.T) {
+	// an update sends only the attributes to change
+	body, _ := json.Marshal(AccountAttributes{Country: "GB"})
+	if string(body) != `{"country":"GB"}` {
+		t.Errorf("Expected the unset attributes to be omitted, got %s", body)
+	}
+}
+
+func TestPersonalDataFieldsAreAttributes(t *testing.T) {
+	body, _ := json.Marshal(AccountAttributes{
+		AccountNumber:               "41426819",
+		Iban:                        "GB16NWBK40030041426819",
+		CustomerID:                  "customer",
+		Name:                        []string{"Samantha Holder"},
+		AlternativeNames:            []string{"Sam Holder"},
+		FirstName:                   "Samantha",
+		BankAccountName:             "Samantha Holder",
+		AlternativeBankAccountNames: []string{"Sam Holder"},
+		SecondaryIdentification:     "A1B2C3D4",
+		UserDefinedInformation:      "customer since 2010",
+		PrivateIdentification:       &PrivateIdentification{Identification: "13YH458762"},
+		OrganisationIdentification:  &OrganisationIdentification{Identification: "123654"},
+		UserDefinedData:             []UserDefinedData{{Key: "reference", Value: "1"}},
+	})
+	var fields map[string]interface{}
+	json.Unmarshal(body, &fields)
+
+	for _, field := range PersonalDataFields {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("%v is not an attribute of the account", field)
+		}
+	}
+}
+
+func TestAccountAttributesIdentificationRoundTrip(t *testing.T) {
+	body := `{
+		"name": ["Samantha Holder"],
+		"alternative_names": ["Sam Holder"],
+		"processing_service": "ABC Bank",
+		"user_defined_information": "Some important info",
+		"validation_type": "card",
+		"reference_mask": "############",
+		"acceptance_qualifier": "same_day",
+		"name_matching_status": "opted_out",
+		"private_identification": {
+			"birth_date": "2017-07-23",
+			"birth_country": "GB",
+			"identification": "13YH458762",
+			"address": ["10 Avenue des Champs"],
+			"city": "London",
+			"country": "GB"
+		},
+		"organisation_identification": {
+			"identification": "123654",
+			"actors": [{"name": ["Jeff Page"], "birth_date": "1970-01-01", "residency": "GB"}],
+			"address": ["10 Avenue des Champs"],
+			"city": "London",
+			"country": "GB"
+		},
+		"user_defined_data": [{"key": "Some account related key", "value": "Some account related value"}]
+	}`
+
+	var attributes AccountAttributes
+	if err := json.Unmarshal([]byte(body), &attributes); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(attributes.Name) != 1 || len(attributes.AlternativeNames) != 1 || attributes.ProcessingService != "ABC Bank" ||
+		attributes.UserDefinedInformation == "" || attributes.ValidationType != "card" || attributes.ReferenceMask == "" ||
+		attributes.AcceptanceQualifier != "same_day" || attributes.NameMatchingStatus != "opted_out" {
+		t.Errorf("Attributes not decoded: %+v", attributes)
+	}
+	if id := attributes.PrivateIdentification; id == nil || id.BirthDate != "2017-07-23" || id.Identification != "13YH458762" || len(id.Address) != 1 || id.City != "London" {
+		t.Errorf("Private identification not decoded: %+v", id)
+	}
+	id := attributes.OrganisationIdentification
+	if id == nil || id.Identification != "123654" || len(id.Actors) != 1 || id.Actors[0].Name[0] != "Jeff Page" || id.Actors[0].Residency != "GB" {
+		t.Fatalf("Organisation identification not decoded: %+v", id)
+	}
+	if len(attributes.UserDefinedData) != 1 || attributes.UserDefinedData[0].Key != "Some account related key" {
+		t.Errorf("User defined data not decoded: %+v", attributes.UserDefinedData)
+	}
+
+	encoded, _ := json.Marshal(attributes)
+	var decoded AccountAttributes
+	json.Unmarshal(encoded, &decoded)
+	if decoded.OrganisationIdentification.Actors[0].BirthDate != "1970-01-01" || decoded.PrivateIdentification.BirthCountry != "GB" {
+		t.Errorf("Identification lost in the round trip: %s", encoded)
+	}
+}
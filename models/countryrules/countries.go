@@ -0,0 +1,150 @@
+package countryrules
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// the rules of the countries supported by Form3
+func init() {
+	Register(Rule{
+		Country:       "GB",
+		BankID:        digits(Required, 6),
+		BankIDCode:    code(Required, "GBDSC"),
+		Bic:           bic(Required),
+		AccountNumber: digits(Optional, 8),
+	})
+	Register(Rule{
+		Country:       "AU",
+		BankID:        digits(Optional, 6),
+		BankIDCode:    code(Required, "AUBSB"),
+		Bic:           bic(Required),
+		AccountNumber: Field{Requirement: Optional, Pattern: regexp.MustCompile(`^[1-9][0-9]{5,9}$`), Format: "6 to 10 digits not starting with 0"},
+		Iban:          Field{Requirement: NotSupported},
+	})
+	Register(Rule{
+		Country:       "BE",
+		BankID:        digits(Required, 3),
+		BankIDCode:    code(Required, "BE"),
+		Bic:           bic(Optional),
+		AccountNumber: digits(Optional, 7),
+	})
+	Register(Rule{
+		Country:       "CA",
+		BankID:        Field{Requirement: Optional, Pattern: regexp.MustCompile(`^0[0-9]{8}$`), Format: "9 digits starting with 0"},
+		BankIDCode:    code(Optional, "CACPA"),
+		Bic:           bic(Required),
+		AccountNumber: digitRange(Optional, 7, 12),
+		Iban:          Field{Requirement: NotSupported},
+	})
+	Register(Rule{
+		Country:       "FR",
+		BankID:        digits(Required, 10),
+		BankIDCode:    code(Required, "FR"),
+		Bic:           bic(Optional),
+		AccountNumber: alphanumeric(Optional, 11),
+	})
+	Register(Rule{
+		Country:       "DE",
+		BankID:        digits(Required, 8),
+		BankIDCode:    code(Required, "DEBLZ"),
+		Bic:           bic(Optional),
+		AccountNumber: digits(Optional, 7),
+	})
+	Register(Rule{
+		Country:       "GR",
+		BankID:        digits(Required, 7),
+		BankIDCode:    code(Required, "GRBIC"),
+		Bic:           bic(Optional),
+		AccountNumber: digits(Optional, 16),
+	})
+	Register(Rule{
+		Country:       "HK",
+		BankID:        digits(Optional, 3),
+		BankIDCode:    code(Optional, "HKNCC"),
+		Bic:           bic(Required),
+		AccountNumber: digitRange(Optional, 9, 12),
+		Iban:          Field{Requirement: NotSupported},
+	})
+	Register(Rule{
+		Country:       "IT",
+		BankID:        digitRange(Required, 10, 11),
+		BankIDCode:    code(Required, "ITNCC"),
+		Bic:           bic(Optional),
+		AccountNumber: alphanumeric(Optional, 12),
+	})
+	Register(Rule{
+		Country:       "LU",
+		BankID:        digits(Required, 3),
+		BankIDCode:    code(Required, "LULUX"),
+		Bic:           bic(Optional),
+		AccountNumber: alphanumeric(Optional, 13),
+	})
+	Register(Rule{
+		Country:       "NL",
+		BankID:        Field{Requirement: NotSupported},
+		BankIDCode:    Field{Requirement: NotSupported},
+		Bic:           bic(Required),
+		AccountNumber: digits(Optional, 10),
+	})
+	Register(Rule{
+		Country:       "PL",
+		BankID:        digits(Required, 8),
+		BankIDCode:    code(Required, "PLKNR"),
+		Bic:           bic(Optional),
+		AccountNumber: digits(Optional, 16),
+	})
+	Register(Rule{
+		Country:       "PT",
+		BankID:        digits(Required, 8),
+		BankIDCode:    code(Required, "PTNCC"),
+		Bic:           bic(Optional),
+		AccountNumber: digits(Optional, 11),
+	})
+	Register(Rule{
+		Country:       "ES",
+		BankID:        digits(Required, 8),
+		BankIDCode:    code(Required, "ESNCC"),
+		Bic:           bic(Optional),
+		AccountNumber: digits(Optional, 10),
+	})
+	Register(Rule{
+		Country:       "CH",
+		BankID:        digits(Required, 5),
+		BankIDCode:    code(Required, "CHBCC"),
+		Bic:           bic(Optional),
+		AccountNumber: alphanumeric(Optional, 12),
+	})
+	Register(Rule{
+		Country:       "US",
+		BankID:        digits(Required, 9),
+		BankIDCode:    code(Required, "USABA"),
+		Bic:           bic(Required),
+		AccountNumber: digitRange(Optional, 6, 17),
+		Iban:          Field{Requirement: NotSupported},
+	})
+}
+
+func digits(requirement Requirement, length int) Field {
+	n := strconv.Itoa(length)
+	return Field{Requirement: requirement, Pattern: regexp.MustCompile(`^[0-9]{` + n + `}$`), Format: n + " digits"}
+}
+
+func digitRange(requirement Requirement, min int, max int) Field {
+	from, to := strconv.Itoa(min), strconv.Itoa(max)
+	return Field{Requirement: requirement, Pattern: regexp.MustCompile(`^[0-9]{` + from + `,` + to + `}$`), Format: from + " to " + to + " digits"}
+}
+
+func alphanumeric(requirement Requirement, length int) Field {
+	n := strconv.Itoa(length)
+	return Field{Requirement: requirement, Pattern: regexp.MustCompile(`^[0-9A-Z]{` + n + `}$`), Format: n + " characters"}
+}
+
+func code(requirement Requirement, values ...string) Field {
+	return Field{Requirement: requirement, Values: values}
+}
+
+// bic only sets the requirement, the format of a BIC does not depend on the country
+func bic(requirement Requirement) Field {
+	return Field{Requirement: requirement}
+}
@@ -0,0 +1,195 @@
+// Package countryrules describes the country specific rules of the account attributes identifying a bank account.
+// https://api-docs.form3.tech/api.html#organisation-accounts-create-supported-countries
+package countryrules
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// JSON names of the attributes governed by the rules
+const (
+	FieldBankID        = "bank_id"
+	FieldBankIDCode    = "bank_id_code"
+	FieldBic           = "bic"
+	FieldAccountNumber = "account_number"
+	FieldIban          = "iban"
+)
+
+// Requirement tells whether an attribute must, may or must not be sent for a country
+type Requirement int
+
+const (
+	// Optional attributes may be left empty
+	Optional Requirement = iota
+	// Required attributes must be set
+	Required
+	// NotSupported attributes must be left empty
+	NotSupported
+)
+
+func (r Requirement) String() string {
+	switch r {
+	case Required:
+		return "required"
+	case NotSupported:
+		return "not supported"
+	default:
+		return "optional"
+	}
+}
+
+// Field is the rule of a single attribute.
+// The zero value accepts any value.
+type Field struct {
+	Requirement Requirement
+
+	// Pattern is the format of the value, nil accepts any format
+	Pattern *regexp.Regexp
+
+	// Format describes the pattern in the error messages, e.g. "6 digits"
+	Format string
+
+	// Values lists the accepted values, empty accepts any value
+	Values []string
+}
+
+// Check returns why the value does not follow the rule, or an empty string if it does
+func (f Field) Check(value string) string {
+	if value == "" {
+		if f.Requirement == Required {
+			return "is required"
+		}
+		return ""
+	}
+
+	if f.Requirement == NotSupported {
+		return "is not supported"
+	}
+
+	if f.Pattern != nil && !f.Pattern.MatchString(value) {
+		return "must be " + f.Format
+	}
+
+	if len(f.Values) > 0 && !contains(f.Values, value) {
+		return "must be " + strings.Join(f.Values, " or ")
+	}
+
+	return ""
+}
+
+// Hint summarises the rule, e.g. "required, 6 digits"
+func (f Field) Hint() string {
+	hints := []string{f.Requirement.String()}
+	if f.Format != "" {
+		hints = append(hints, f.Format)
+	}
+	if len(f.Values) > 0 {
+		hints = append(hints, strings.Join(f.Values, " or "))
+	}
+	return strings.Join(hints, ", ")
+}
+
+// Rule lists the rules of the attributes of the accounts domiciled in a country.
+// The zero value, returned for unknown countries, accepts any value.
+type Rule struct {
+	// Country is the ISO 3166-1 code of the country
+	Country string
+
+	BankID        Field
+	BankIDCode    Field
+	Bic           Field
+	AccountNumber Field
+	Iban          Field
+}
+
+// Violation is an attribute not following the rule of its country
+type Violation struct {
+	// Field is the JSON name of the attribute, e.g. bank_id
+	Field string
+
+	Message string
+}
+
+// Check returns the violations of the attributes, passed through by their JSON name
+func (r Rule) Check(values map[string]string) []Violation {
+	var violations []Violation
+	for _, field := range r.fields() {
+		if message := field.rule.Check(values[field.name]); message != "" {
+			if r.Country != "" {
+				message = message + " for country " + r.Country
+			}
+			violations = append(violations, Violation{Field: field.name, Message: message})
+		}
+	}
+	return violations
+}
+
+// Field returns the rule of an attribute from its JSON name
+func (r Rule) Field(name string) Field {
+	for _, field := range r.fields() {
+		if field.name == name {
+			return field.rule
+		}
+	}
+	return Field{}
+}
+
+type namedField struct {
+	name string
+	rule Field
+}
+
+func (r Rule) fields() []namedField {
+	return []namedField{
+		{FieldBankID, r.BankID},
+		{FieldBankIDCode, r.BankIDCode},
+		{FieldBic, r.Bic},
+		{FieldAccountNumber, r.AccountNumber},
+		{FieldIban, r.Iban},
+	}
+}
+
+var (
+	mutex sync.RWMutex
+	rules = make(map[string]Rule)
+)
+
+// Register adds the rule of a country, replacing the existing one if any
+func Register(rule Rule) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	rules[rule.Country] = rule
+}
+
+// Lookup returns the rule of a country.
+// It returns false and a rule accepting any value when the country has no rule.
+func Lookup(country string) (Rule, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	rule, ok := rules[country]
+	return rule, ok
+}
+
+// Countries returns the sorted codes of the countries having a rule
+func Countries() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	countries := make([]string, 0, len(rules))
+	for country := range rules {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package countryrules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupKnownCountry(t *testing.T) {
+	rule, ok := Lookup("GB")
+	if !ok {
+		t.Fatal("Expected a rule for GB")
+	}
+	if rule.BankID.Requirement != Required || rule.Iban.Requirement != Optional {
+		t.Errorf("Unexpected rule for GB: %+v", rule)
+	}
+}
+
+func TestLookupUnknownCountryAcceptsAnything(t *testing.T) {
+	rule, ok := Lookup("ZZ")
+	if ok {
+		t.Fatal("Expected no rule for ZZ")
+	}
+
+	violations := rule.Check(map[string]string{FieldBankID: "anything", FieldIban: "anything"})
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations but got %v", violations)
+	}
+}
+
+func TestCheckValidAttributes(t *testing.T) {
+	rule, _ := Lookup("GB")
+	violations := rule.Check(map[string]string{
+		FieldBankID:        "400300",
+		FieldBankIDCode:    "GBDSC",
+		FieldBic:           "NWBKGB22",
+		FieldAccountNumber: "41426819",
+	})
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations but got %v", violations)
+	}
+}
+
+func TestCheckReportsEveryViolation(t *testing.T) {
+	rule, _ := Lookup("US")
+	violations := rule.Check(map[string]string{
+		FieldBankID:        "12345",
+		FieldBankIDCode:    "GBDSC",
+		FieldAccountNumber: "12AB",
+		FieldIban:          "GB16NWBK40030041426819",
+	})
+
+	expected := []Violation{
+		{FieldBankID, "must be 9 digits for country US"},
+		{FieldBankIDCode, "must be USABA for country US"},
+		{FieldBic, "is required for country US"},
+		{FieldAccountNumber, "must be 6 to 17 digits for country US"},
+		{FieldIban, "is not supported for country US"},
+	}
+
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("Unexpected violations\ngot      %v\nexpected %v", violations, expected)
+	}
+}
+
+func TestFieldHint(t *testing.T) {
+	rule, _ := Lookup("GB")
+	if hint := rule.Field(FieldBankID).Hint(); hint != "required, 6 digits" {
+		t.Errorf("Unexpected hint for bank_id: %v", hint)
+	}
+	if hint := rule.Field(FieldBankIDCode).Hint(); hint != "required, GBDSC" {
+		t.Errorf("Unexpected hint for bank_id_code: %v", hint)
+	}
+}
+
+func TestRegisterReplacesRule(t *testing.T) {
+	original, _ := Lookup("NL")
+	defer Register(original)
+
+	Register(Rule{Country: "NL", Bic: Field{Requirement: NotSupported}})
+	rule, _ := Lookup("NL")
+	if rule.Bic.Requirement != NotSupported {
+		t.Errorf("Rule not replaced: %+v", rule)
+	}
+}
+
+func TestCountries(t *testing.T) {
+	countries := Countries()
+	if len(countries) != 16 || countries[0] != "AU" || countries[len(countries)-1] != "US" {
+		t.Errorf("Unexpected countries: %v", countries)
+	}
+}
@@ -1,5 +1,5 @@
-module form3.com/models
+module form3-interview/models
 
 go 1.15
 
-require github.com/google/uuid v1.2.0 // indirect
+require github.com/google/uuid v1.2.0
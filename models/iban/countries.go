@@ -0,0 +1,135 @@
+package iban
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// role is the component of the BBAN a part belongs to
+type role int
+
+const (
+	bank role = iota
+	branch
+	account
+	check
+)
+
+// part is a fixed length section of a BBAN, written like in the IBAN registry, e.g. 4!a
+type part struct {
+	role   role
+	length int
+	// kind is n for digits, a for uppercase letters and c for both
+	kind byte
+}
+
+// spec is the structure of the BBAN of a country
+type spec struct {
+	parts   []part
+	pattern *regexp.Regexp
+}
+
+func (s *spec) length() int {
+	length := 4
+	for _, p := range s.parts {
+		length = length + p.length
+	}
+	return length
+}
+
+func (s *spec) matches(bban string) bool {
+	return s.pattern.MatchString(bban)
+}
+
+// split sets the components of the IBAN from its BBAN
+func (s *spec) split(iban *IBAN) {
+	bban := iban.BBAN
+	for _, p := range s.parts {
+		value := bban[:p.length]
+		bban = bban[p.length:]
+		switch p.role {
+		case bank:
+			iban.BankCode = iban.BankCode + value
+		case branch:
+			iban.BranchCode = iban.BranchCode + value
+		case account:
+			iban.AccountNumber = iban.AccountNumber + value
+		case check:
+			iban.NationalCheck = iban.NationalCheck + value
+		}
+	}
+}
+
+// newSpec creates the spec of a BBAN from its parts, e.g. "bank 4!a", "branch 6!n", "account 8!n"
+func newSpec(parts ...string) *spec {
+	s := &spec{}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	for _, text := range parts {
+		fields := strings.Fields(text)
+		length, _ := strconv.Atoi(strings.TrimRight(fields[1], "!anc"))
+		p := part{
+			role:   map[string]role{"bank": bank, "branch": branch, "account": account, "check": check}[fields[0]],
+			length: length,
+			kind:   fields[1][len(fields[1])-1],
+		}
+		s.parts = append(s.parts, p)
+
+		switch p.kind {
+		case 'n':
+			pattern.WriteString("[0-9]")
+		case 'a':
+			pattern.WriteString("[A-Z]")
+		default:
+			pattern.WriteString("[0-9A-Z]")
+		}
+		pattern.WriteString("{" + strconv.Itoa(length) + "}")
+	}
+
+	pattern.WriteString("$")
+	s.pattern = regexp.MustCompile(pattern.String())
+	return s
+}
+
+// specs are the BBAN structures of the SEPA countries, from the SWIFT IBAN registry
+var specs = map[string]*spec{
+	"AD": newSpec("bank 4!n", "branch 4!n", "account 12!c"),
+	"AT": newSpec("bank 5!n", "account 11!n"),
+	"BE": newSpec("bank 3!n", "account 7!n", "check 2!n"),
+	"BG": newSpec("bank 4!a", "branch 4!n", "account 10!c"),
+	"CH": newSpec("bank 5!n", "account 12!c"),
+	"CY": newSpec("bank 3!n", "branch 5!n", "account 16!c"),
+	"CZ": newSpec("bank 4!n", "account 16!n"),
+	"DE": newSpec("bank 8!n", "account 10!n"),
+	"DK": newSpec("bank 4!n", "account 10!n"),
+	"EE": newSpec("bank 2!n", "account 14!n"),
+	"ES": newSpec("bank 4!n", "branch 4!n", "check 2!n", "account 10!n"),
+	"FI": newSpec("bank 3!n", "account 11!n"),
+	"FR": newSpec("bank 5!n", "branch 5!n", "account 11!c", "check 2!n"),
+	"GB": newSpec("bank 4!a", "branch 6!n", "account 8!n"),
+	"GI": newSpec("bank 4!a", "account 15!c"),
+	"GR": newSpec("bank 3!n", "branch 4!n", "account 16!c"),
+	"HR": newSpec("bank 7!n", "account 10!n"),
+	"HU": newSpec("bank 3!n", "branch 4!n", "account 17!n"),
+	"IE": newSpec("bank 4!a", "branch 6!n", "account 8!n"),
+	"IS": newSpec("bank 4!n", "account 18!n"),
+	"IT": newSpec("check 1!a", "bank 5!n", "branch 5!n", "account 12!c"),
+	"LI": newSpec("bank 5!n", "account 12!c"),
+	"LT": newSpec("bank 5!n", "account 11!n"),
+	"LU": newSpec("bank 3!n", "account 13!c"),
+	"LV": newSpec("bank 4!a", "account 13!c"),
+	"MC": newSpec("bank 5!n", "branch 5!n", "account 11!c", "check 2!n"),
+	"MT": newSpec("bank 4!a", "branch 5!n", "account 18!c"),
+	"NL": newSpec("bank 4!a", "account 10!n"),
+	"NO": newSpec("bank 4!n", "account 7!n"),
+	"PL": newSpec("bank 8!n", "account 16!n"),
+	"PT": newSpec("bank 4!n", "branch 4!n", "account 11!n", "check 2!n"),
+	"RO": newSpec("bank 4!a", "account 16!c"),
+	"SE": newSpec("bank 3!n", "account 17!n"),
+	"SI": newSpec("bank 5!n", "account 10!n"),
+	"SK": newSpec("bank 4!n", "account 16!n"),
+	"SM": newSpec("check 1!a", "bank 5!n", "branch 5!n", "account 12!c"),
+	"VA": newSpec("bank 3!n", "account 15!n"),
+}
@@ -0,0 +1,206 @@
+package iban
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Generate derives the IBAN of an account from its country, local bank identifier, BIC and account number.
+//
+// The bank identifier is the one sent to Form3 as bank_id, e.g. the sort code of GB accounts or the BLZ of DE accounts.
+// When the bank code of the country is made of letters, e.g. GB or NL, it is read from the first four characters of the BIC.
+// The account number is padded with zeros to the length expected by the country
+// and the national check digits, e.g. the RIB key of FR accounts, are computed.
+func Generate(country string, bankID string, bic string, accountNumber string) (*IBAN, error) {
+	spec, ok := specs[country]
+	if !ok {
+		return nil, fmt.Errorf("iban of country %q: %w", country, ErrUnsupportedCountry)
+	}
+
+	bankID = normalise(bankID)
+	bic = normalise(bic)
+	accountNumber = normalise(accountNumber)
+
+	// the Italian bank ID can start with the CIN check character
+	var providedCheck string
+	if spec.parts[0].role == check && len(bankID) == spec.bankIDLength()+1 {
+		providedCheck, bankID = bankID[:1], bankID[1:]
+	}
+
+	if len(bankID) != spec.bankIDLength() {
+		return nil, fmt.Errorf("bank ID of country %v must have %d characters: %w", country, spec.bankIDLength(), ErrInvalidFormat)
+	}
+
+	accountLength := spec.lengthOf(account)
+	if accountNumber == "" || len(accountNumber) > accountLength {
+		return nil, fmt.Errorf("account number of country %v must have at most %d characters: %w", country, accountLength, ErrInvalidFormat)
+	}
+	accountNumber = strings.Repeat("0", accountLength-len(accountNumber)) + accountNumber
+
+	var bankCode, branchCode string
+	for _, p := range spec.parts {
+		switch {
+		case p.role == bank && p.kind == 'a':
+			if len(bic) < p.length {
+				return nil, fmt.Errorf("the bank code of country %v is read from the BIC, which is missing: %w", country, ErrInvalidFormat)
+			}
+			bankCode = bankCode + bic[:p.length]
+		case p.role == bank:
+			bankCode, bankID = bankCode+bankID[:p.length], bankID[p.length:]
+		case p.role == branch:
+			branchCode, bankID = branchCode+bankID[:p.length], bankID[p.length:]
+		}
+	}
+
+	var nationalCheck string
+	if spec.lengthOf(check) > 0 {
+		nationalCheck = nationalChecks[country](bankCode, branchCode, accountNumber)
+		if nationalCheck == "" {
+			return nil, fmt.Errorf("iban of country %v: %w", country, ErrInvalidFormat)
+		}
+		if providedCheck != "" && providedCheck != nationalCheck {
+			return nil, fmt.Errorf("check character %v of the bank ID: %w", providedCheck, ErrInvalidChecksum)
+		}
+	}
+
+	// the components are assembled in the order of the country
+	var bban strings.Builder
+	values := map[role]string{bank: bankCode, branch: branchCode, account: accountNumber, check: nationalCheck}
+	for _, p := range spec.parts {
+		bban.WriteString(values[p.role][:p.length])
+		values[p.role] = values[p.role][p.length:]
+	}
+
+	return New(country, bban.String())
+}
+
+// bankIDLength is the length of the bank identifier used by Form3,
+// made of the numeric bank code and the branch code
+func (s *spec) bankIDLength() int {
+	length := 0
+	for _, p := range s.parts {
+		if (p.role == bank && p.kind != 'a') || p.role == branch {
+			length = length + p.length
+		}
+	}
+	return length
+}
+
+func (s *spec) lengthOf(r role) int {
+	length := 0
+	for _, p := range s.parts {
+		if p.role == r {
+			length = length + p.length
+		}
+	}
+	return length
+}
+
+// nationalChecks compute the national check digits of the countries having them.
+// They return an empty string when the components can not be checked.
+var nationalChecks = map[string]func(bankCode string, branchCode string, accountNumber string) string{
+	"BE": belgianCheck,
+	"ES": spanishCheck,
+	"FR": ribKey,
+	"MC": ribKey,
+	"IT": italianCheck,
+	"SM": italianCheck,
+	"PT": portugueseCheck,
+}
+
+func belgianCheck(bankCode string, branchCode string, accountNumber string) string {
+	number, err := strconv.ParseInt(bankCode+accountNumber, 10, 64)
+	if err != nil {
+		return ""
+	}
+	remainder := number % 97
+	if remainder == 0 {
+		remainder = 97
+	}
+	return fmt.Sprintf("%02d", remainder)
+}
+
+func spanishCheck(bankCode string, branchCode string, accountNumber string) string {
+	weights := []int{1, 2, 4, 8, 5, 10, 9, 7, 3, 6}
+	digit := func(value string) string {
+		sum := 0
+		for i, r := range value {
+			sum = sum + int(r-'0')*weights[i]
+		}
+		d := 11 - sum%11
+		switch d {
+		case 11:
+			d = 0
+		case 10:
+			d = 1
+		}
+		return strconv.Itoa(d)
+	}
+	if !isDigits(bankCode + branchCode + accountNumber) {
+		return ""
+	}
+	return digit("00"+bankCode+branchCode) + digit(accountNumber)
+}
+
+// ribKey computes the key of the French Relevé d'Identité Bancaire
+func ribKey(bankCode string, branchCode string, accountNumber string) string {
+	// the letters of the account number are replaced by digits
+	letters := map[rune]rune{
+		'A': '1', 'J': '1',
+		'B': '2', 'K': '2', 'S': '2',
+		'C': '3', 'L': '3', 'T': '3',
+		'D': '4', 'M': '4', 'U': '4',
+		'E': '5', 'N': '5', 'V': '5',
+		'F': '6', 'O': '6', 'W': '6',
+		'G': '7', 'P': '7', 'X': '7',
+		'H': '8', 'Q': '8', 'Y': '8',
+		'I': '9', 'R': '9', 'Z': '9',
+	}
+	account := strings.Map(func(r rune) rune {
+		if digit, ok := letters[r]; ok {
+			return digit
+		}
+		return r
+	}, accountNumber)
+
+	bank, err1 := strconv.ParseInt(bankCode, 10, 64)
+	branch, err2 := strconv.ParseInt(branchCode, 10, 64)
+	number, err3 := strconv.ParseInt(account, 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return ""
+	}
+	return fmt.Sprintf("%02d", 97-(89*bank+15*branch+3*number)%97)
+}
+
+// italianCheck computes the CIN check character of Italian and Sammarinese accounts
+func italianCheck(bankCode string, branchCode string, accountNumber string) string {
+	odd := []int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21, 2, 4, 18, 20, 11, 3, 6, 8, 12, 14, 16, 10, 22, 25, 24, 23}
+
+	sum := 0
+	for i, r := range bankCode + branchCode + accountNumber {
+		var value int
+		switch {
+		case r >= '0' && r <= '9':
+			value = int(r - '0')
+		case r >= 'A' && r <= 'Z':
+			value = int(r - 'A')
+		default:
+			return ""
+		}
+		// positions are counted from one, odd positions use the conversion table
+		if i%2 == 0 {
+			value = odd[value]
+		}
+		sum = sum + value
+	}
+	return string(rune('A' + sum%26))
+}
+
+func portugueseCheck(bankCode string, branchCode string, accountNumber string) string {
+	remainder := mod97(bankCode + branchCode + accountNumber + "00")
+	if remainder < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%02d", 98-remainder)
+}
@@ -0,0 +1,169 @@
+// Package iban parses, validates and generates the International Bank Account Numbers of the SEPA countries.
+// https://www.swift.com/standards/data-standards/iban
+package iban
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedCountry is returned for the IBANs of a country outside of the SEPA area
+	ErrUnsupportedCountry = errors.New("country not supported")
+
+	// ErrInvalidLength is returned when the IBAN does not have the length of its country
+	ErrInvalidLength = errors.New("invalid length")
+
+	// ErrInvalidFormat is returned when the BBAN does not follow the structure of its country
+	ErrInvalidFormat = errors.New("invalid format")
+
+	// ErrInvalidChecksum is returned when the check digits of the IBAN are wrong
+	ErrInvalidChecksum = errors.New("invalid checksum")
+)
+
+// IBAN is a parsed International Bank Account Number
+type IBAN struct {
+	// Country is the ISO 3166-1 code of the country, e.g. GB
+	Country string
+
+	// CheckDigits are the two digits validating the IBAN
+	CheckDigits string
+
+	// BBAN is the national Basic Bank Account Number
+	BBAN string
+
+	// BankCode identifies the bank, e.g. NWBK
+	BankCode string
+
+	// BranchCode identifies the branch when the country has one, e.g. the sort code of GB accounts
+	BranchCode string
+
+	// AccountNumber identifies the account in the bank
+	AccountNumber string
+
+	// NationalCheck holds the national check digits when the country has them, e.g. the RIB key of FR accounts
+	NationalCheck string
+}
+
+// Parse validates an IBAN in either its electronic or its print format and extracts its components
+func Parse(value string) (*IBAN, error) {
+	value = normalise(value)
+	if len(value) < 4 {
+		return nil, fmt.Errorf("iban %q: %w", value, ErrInvalidLength)
+	}
+
+	spec, ok := specs[value[:2]]
+	if !ok {
+		return nil, fmt.Errorf("iban %q: %w", value, ErrUnsupportedCountry)
+	}
+	if len(value) != spec.length() {
+		return nil, fmt.Errorf("iban %q: %w, expected %d characters", value, ErrInvalidLength, spec.length())
+	}
+	if !isDigits(value[2:4]) || !spec.matches(value[4:]) {
+		return nil, fmt.Errorf("iban %q: %w", value, ErrInvalidFormat)
+	}
+	if mod97(value[4:]+value[:4]) != 1 {
+		return nil, fmt.Errorf("iban %q: %w", value, ErrInvalidChecksum)
+	}
+
+	iban := &IBAN{
+		Country:     value[:2],
+		CheckDigits: value[2:4],
+		BBAN:        value[4:],
+	}
+	spec.split(iban)
+
+	return iban, nil
+}
+
+// Validate checks an IBAN in either its electronic or its print format
+func Validate(value string) error {
+	_, err := Parse(value)
+	return err
+}
+
+// ValidChecksum checks the mod-97 checksum of an IBAN of any country, without checking the structure of its BBAN.
+// It can be used for the IBANs of the countries outside of the SEPA area.
+func ValidChecksum(value string) bool {
+	value = normalise(value)
+	if len(value) < 5 || len(value) > 34 || !isLetters(value[:2]) || !isDigits(value[2:4]) {
+		return false
+	}
+	return mod97(value[4:]+value[:4]) == 1
+}
+
+// Supported reports whether the IBANs of a country can be parsed
+func Supported(country string) bool {
+	_, ok := specs[country]
+	return ok
+}
+
+// String returns the IBAN in its electronic format, e.g. GB29NWBK60161331926819
+func (i *IBAN) String() string {
+	return i.Country + i.CheckDigits + i.BBAN
+}
+
+// PrintFormat returns the IBAN in groups of four characters, e.g. GB29 NWBK 6016 1331 9268 19
+func (i *IBAN) PrintFormat() string {
+	value := i.String()
+	var groups []string
+	for len(value) > 4 {
+		groups = append(groups, value[:4])
+		value = value[4:]
+	}
+	return strings.Join(append(groups, value), " ")
+}
+
+// New builds the IBAN of a BBAN, computing its check digits
+func New(country string, bban string) (*IBAN, error) {
+	bban = normalise(bban)
+	checkDigits := 98 - mod97(bban+country+"00")
+	return Parse(country + fmt.Sprintf("%02d", checkDigits) + bban)
+}
+
+// normalise removes the spaces of the print format and uppercases the letters
+func normalise(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), ""))
+}
+
+// mod97 returns the remainder of the division by 97 of the value, letters being converted to 10 to 35
+func mod97(value string) int {
+	var digits strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		default:
+			return -1
+		}
+	}
+
+	number, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return -1
+	}
+	return int(new(big.Int).Mod(number, big.NewInt(97)).Int64())
+}
+
+func isLetters(value string) bool {
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
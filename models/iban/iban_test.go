@@ -0,0 +1,171 @@
+package iban
+
+import (
+	"errors"
+	"testing"
+)
+
+// examples of the SWIFT IBAN registry
+var registryExamples = []string{
+	"AD1200012030200359100100",
+	"AT611904300234573201",
+	"BE68539007547034",
+	"BG80BNBG96611020345678",
+	"CH9300762011623852957",
+	"CY17002001280000001200527600",
+	"CZ6508000000192000145399",
+	"DE89370400440532013000",
+	"DK5000400440116243",
+	"EE382200221020145685",
+	"ES9121000418450200051332",
+	"FI2112345600000785",
+	"FR1420041010050500013M02606",
+	"GB29NWBK60161331926819",
+	"GI75NWBK000000007099453",
+	"GR1601101250000000012300695",
+	"HR1210010051863000160",
+	"HU42117730161111101800000000",
+	"IE29AIBK93115212345678",
+	"IS140159260076545510730339",
+	"IT60X0542811101000000123456",
+	"LI21088100002324013AA",
+	"LT121000011101001000",
+	"LU280019400644750000",
+	"LV80BANK0000435195001",
+	"MC5811222000010123456789030",
+	"MT84MALT011000012345MTLCAST001S",
+	"NL91ABNA0417164300",
+	"NO9386011117947",
+	"PL61109010140000071219812874",
+	"PT50000201231234567890154",
+	"RO49AAAA1B31007593840000",
+	"SE4550000000058398257466",
+	"SI56263300012039086",
+	"SK3112000000198742637541",
+	"SM86U0322509800000000270100",
+	"VA59001123000012345678",
+}
+
+func TestParseRegistryExamples(t *testing.T) {
+	for _, example := range registryExamples {
+		iban, err := Parse(example)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %v: %v", example, err)
+			continue
+		}
+		if iban.String() != example {
+			t.Errorf("Expected %v but got %v", example, iban.String())
+		}
+	}
+}
+
+func TestParseComponents(t *testing.T) {
+	iban, err := Parse("GB29 NWBK 6016 1331 9268 19")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := IBAN{
+		Country:       "GB",
+		CheckDigits:   "29",
+		BBAN:          "NWBK60161331926819",
+		BankCode:      "NWBK",
+		BranchCode:    "601613",
+		AccountNumber: "31926819",
+	}
+	if *iban != expected {
+		t.Errorf("Unexpected components\ngot      %+v\nexpected %+v", *iban, expected)
+	}
+
+	iban, _ = Parse("FR1420041010050500013M02606")
+	if iban.BankCode != "20041" || iban.BranchCode != "01005" || iban.AccountNumber != "0500013M026" || iban.NationalCheck != "06" {
+		t.Errorf("Unexpected components %+v", *iban)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		value string
+		err   error
+	}{
+		{"GB28NWBK60161331926819", ErrInvalidChecksum},
+		{"GB29NWBK6016133192681", ErrInvalidLength},
+		{"GB291WBK60161331926819", ErrInvalidFormat},
+		{"GBAANWBK60161331926819", ErrInvalidFormat},
+		{"US29NWBK60161331926819", ErrUnsupportedCountry},
+		{"GB", ErrInvalidLength},
+		{"", ErrInvalidLength},
+	}
+
+	for _, test := range tests {
+		err := Validate(test.value)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: expected %v but got %v", test.value, test.err, err)
+		}
+	}
+}
+
+func TestPrintFormat(t *testing.T) {
+	iban, _ := Parse("GB29NWBK60161331926819")
+	if iban.PrintFormat() != "GB29 NWBK 6016 1331 9268 19" {
+		t.Errorf("Unexpected print format %v", iban.PrintFormat())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		country       string
+		bankID        string
+		bic           string
+		accountNumber string
+		expected      string
+	}{
+		{"GB", "601613", "NWBKGB22", "31926819", "GB29NWBK60161331926819"},
+		{"NL", "", "ABNANL2A", "417164300", "NL91ABNA0417164300"},
+		{"DE", "37040044", "", "532013000", "DE89370400440532013000"},
+		{"BE", "539", "", "0075470", "BE68539007547034"},
+		{"ES", "21000418", "", "0200051332", "ES9121000418450200051332"},
+		{"FR", "2004101005", "", "0500013M026", "FR1420041010050500013M02606"},
+		{"IT", "0542811101", "", "123456", "IT60X0542811101000000123456"},
+		{"IT", "X0542811101", "", "123456", "IT60X0542811101000000123456"},
+		{"PT", "00020123", "", "12345678901", "PT50000201231234567890154"},
+		{"PL", "10901014", "", "0000071219812874", "PL61109010140000071219812874"},
+		{"LU", "001", "", "9400644750000", "LU280019400644750000"},
+		{"CH", "00762", "", "011623852957", "CH9300762011623852957"},
+	}
+
+	for _, test := range tests {
+		iban, err := Generate(test.country, test.bankID, test.bic, test.accountNumber)
+		if err != nil {
+			t.Errorf("Unexpected error generating the iban of %v: %v", test.expected, err)
+			continue
+		}
+		if iban.String() != test.expected {
+			t.Errorf("Expected %v but got %v", test.expected, iban.String())
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		country       string
+		bankID        string
+		bic           string
+		accountNumber string
+		err           error
+	}{
+		{"US", "123456789", "", "12345678", ErrUnsupportedCountry},
+		{"GB", "60161", "NWBKGB22", "31926819", ErrInvalidFormat},
+		{"GB", "601613", "", "31926819", ErrInvalidFormat},
+		{"GB", "601613", "NWBKGB22", "319268190", ErrInvalidFormat},
+		{"GB", "601613", "NWBKGB22", "", ErrInvalidFormat},
+		{"IT", "A0542811101", "", "123456", ErrInvalidChecksum},
+	}
+
+	for _, test := range tests {
+		_, err := Generate(test.country, test.bankID, test.bic, test.accountNumber)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%+v: expected %v but got %v", test, test.err, err)
+		}
+	}
+}
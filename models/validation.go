@@ -0,0 +1,263 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"form3-interview/models/countryrules"
+	"form3-interview/models/iban"
+
+	"github.com/google/uuid"
+)
+
+// maxAlternativeBankAccountNames is the number of alternative names accepted by the API
+const maxAlternativeBankAccountNames = 3
+
+// maxNameLines is the number of lines of a name accepted by the API
+const maxNameLines = 4
+
+// maxNameLength is the number of characters of a line of a name accepted by the API
+const maxNameLength = 140
+
+// birthDateLayout is the format of the birth dates
+const birthDateLayout = "2006-01-02"
+
+var bicPattern = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// ValidationError describes a field of an account that is not valid
+type ValidationError struct {
+	// Field is the JSON path of the field, e.g. attributes.country
+	Field string
+
+	// Message explains why the value is rejected
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors lists every field of an account that is not valid
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "invalid account: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationErrors) add(field string, message string) {
+	*e = append(*e, ValidationError{Field: field, Message: message})
+}
+
+// err returns nil when there is no error so that the result can be compared to nil
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate checks the account before it is sent to the API.
+// It returns ValidationErrors listing every offending field, or nil if the account is valid.
+func (a *Account) Validate() error {
+	var errs ValidationErrors
+
+	if a.Type != "accounts" {
+		errs.add("type", `must be "accounts"`)
+	}
+	if a.ID == uuid.Nil {
+		errs.add("id", "is required")
+	}
+	if a.OrganisationID == uuid.Nil {
+		errs.add("organisation_id", "is required")
+	}
+
+	if a.Attributes == nil {
+		errs.add("attributes", "is required")
+		return errs.err()
+	}
+
+	if err := a.Attributes.Validate(); err != nil {
+		for _, attributeErr := range err.(ValidationErrors) {
+			errs.add("attributes."+attributeErr.Field, attributeErr.Message)
+		}
+	}
+
+	return errs.err()
+}
+
+// Validate checks the attributes of an account.
+// The fields of the returned ValidationErrors are relative to the attributes object.
+func (a *AccountAttributes) Validate() error {
+	var errs ValidationErrors
+
+	if a.Country == "" {
+		errs.add("country", "is required")
+	} else if !IsCountryCode(a.Country) {
+		errs.add("country", "must be an ISO 3166-1 alpha-2 country code")
+	}
+
+	if a.BaseCurrency != "" && !IsCurrencyCode(a.BaseCurrency) {
+		errs.add("base_currency", "must be an ISO 4217 currency code")
+	}
+
+	if a.Bic != "" && !bicPattern.MatchString(a.Bic) {
+		errs.add("bic", "must be a SWIFT BIC of 8 or 11 characters")
+	}
+
+	if a.Iban != "" {
+		if message := a.checkIban(); message != "" {
+			errs.add("iban", message)
+		}
+	}
+
+	switch a.AccountClassification {
+	case "", "Personal", "Business":
+	default:
+		errs.add("account_classification", `must be "Personal" or "Business"`)
+	}
+
+	if len(a.AlternativeBankAccountNames) > maxAlternativeBankAccountNames {
+		errs.add("alternative_bank_account_names", "must not contain more than 3 names")
+	}
+	for i, name := range a.AlternativeBankAccountNames {
+		if strings.TrimSpace(name) == "" {
+			errs.add("alternative_bank_account_names["+strconv.Itoa(i)+"]", "must not be empty")
+		}
+	}
+
+	errs.checkNames("name", a.Name, maxNameLines)
+	errs.checkNames("alternative_names", a.AlternativeNames, maxAlternativeBankAccountNames)
+
+	switch a.NameMatchingStatus {
+	case "", "supported", "switched", "opted_out", "not_supported":
+	default:
+		errs.add("name_matching_status", `must be "supported", "switched", "opted_out" or "not_supported"`)
+	}
+
+	if id := a.PrivateIdentification; id != nil {
+		errs.checkBirthDate("private_identification.birth_date", id.BirthDate)
+		errs.checkCountry("private_identification.birth_country", id.BirthCountry)
+		errs.checkCountry("private_identification.country", id.Country)
+	}
+	if id := a.OrganisationIdentification; id != nil {
+		errs.checkCountry("organisation_identification.country", id.Country)
+		for i, actor := range id.Actors {
+			field := "organisation_identification.actors[" + strconv.Itoa(i) + "]"
+			errs.checkNames(field+".name", actor.Name, maxNameLines)
+			errs.checkBirthDate(field+".birth_date", actor.BirthDate)
+			errs.checkCountry(field+".residency", actor.Residency)
+		}
+	}
+
+	for i, data := range a.UserDefinedData {
+		if data.Key == "" {
+			errs.add("user_defined_data["+strconv.Itoa(i)+"].key", "is required")
+		}
+	}
+
+	rule, _ := countryrules.Lookup(a.Country)
+	violations := rule.Check(map[string]string{
+		countryrules.FieldBankID:        a.BankID,
+		countryrules.FieldBankIDCode:    a.BankIDCode,
+		countryrules.FieldBic:           a.Bic,
+		countryrules.FieldAccountNumber: a.AccountNumber,
+		countryrules.FieldIban:          a.Iban,
+	})
+	for _, violation := range violations {
+		errs.add(violation.Field, violation.Message)
+	}
+
+	return errs.err()
+}
+
+// checkNames adds an error when there are more than max names or a name is empty or too long
+func (e *ValidationErrors) checkNames(field string, names []string, max int) {
+	if len(names) > max {
+		e.add(field, "must not contain more than "+strconv.Itoa(max)+" names")
+	}
+	for i, name := range names {
+		if strings.TrimSpace(name) == "" {
+			e.add(field+"["+strconv.Itoa(i)+"]", "must not be empty")
+		} else if len([]rune(name)) > maxNameLength {
+			e.add(field+"["+strconv.Itoa(i)+"]", "must not be longer than "+strconv.Itoa(maxNameLength)+" characters")
+		}
+	}
+}
+
+// checkBirthDate adds an error when the date is set but not in the YYYY-MM-DD format
+func (e *ValidationErrors) checkBirthDate(field string, date string) {
+	if _, err := time.Parse(birthDateLayout, date); date != "" && err != nil {
+		e.add(field, "must be a date in the YYYY-MM-DD format")
+	}
+}
+
+// checkCountry adds an error when the country is set but not an ISO 3166-1 code
+func (e *ValidationErrors) checkCountry(field string, country string) {
+	if country != "" && !IsCountryCode(country) {
+		e.add(field, "must be an ISO 3166-1 alpha-2 country code")
+	}
+}
+
+// checkIban returns why the IBAN is not valid, or an empty string if it is.
+// The IBAN of a SEPA country must also match the country, bank ID and account number of the account when they are set.
+func (a *AccountAttributes) checkIban() string {
+	parsed, err := iban.Parse(a.Iban)
+	if errors.Is(err, iban.ErrUnsupportedCountry) {
+		if !iban.ValidChecksum(a.Iban) {
+			return "is not a valid IBAN"
+		}
+		return ""
+	}
+	switch {
+	case errors.Is(err, iban.ErrInvalidLength):
+		return "is not a valid IBAN: invalid length"
+	case errors.Is(err, iban.ErrInvalidChecksum):
+		return "is not a valid IBAN: invalid checksum"
+	case err != nil:
+		return "is not a valid IBAN: invalid format"
+	}
+
+	if a.Country != "" && parsed.Country != a.Country {
+		return "must be an IBAN of country " + a.Country
+	}
+
+	if a.BankID != "" && a.AccountNumber != "" {
+		derived, err := a.DeriveIban()
+		if err == nil && derived != parsed.String() {
+			return "does not match the bank_id and account_number, expected " + derived
+		}
+	}
+
+	return ""
+}
+
+// DeriveIban computes the IBAN of the account from its country, bank ID, BIC and account number.
+// It returns an error when the country is outside of the SEPA area or when the attributes are not enough.
+func (a *AccountAttributes) DeriveIban() (string, error) {
+	derived, err := iban.Generate(a.Country, a.BankID, a.Bic, a.AccountNumber)
+	if err != nil {
+		return "", err
+	}
+	return derived.String(), nil
+}
+
+// FillIban sets the IBAN derived from the other attributes when it is empty
+func (a *AccountAttributes) FillIban() error {
+	if a.Iban != "" {
+		return nil
+	}
+
+	derived, err := a.DeriveIban()
+	if err != nil {
+		return err
+	}
+	a.Iban = derived
+	return nil
+}
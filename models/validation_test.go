@@ -0,0 +1,258 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func validAccount() Account {
+	return Account{
+		Type:           "accounts",
+		ID:             uuid.New(),
+		OrganisationID: uuid.New(),
+		Attributes: &AccountAttributes{
+			Country:                     "GB",
+			BaseCurrency:                "GBP",
+			BankID:                      "400300",
+			BankIDCode:                  "GBDSC",
+			Bic:                         "NWBKGB22",
+			Iban:                        "GB16NWBK40030041426819",
+			AccountClassification:       "Personal",
+			AlternativeBankAccountNames: []string{"Sam Holder"},
+		},
+	}
+}
+
+func TestValidateValidAccount(t *testing.T) {
+	account := validAccount()
+	if err := account.Validate(); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+}
+
+func TestValidateListsEveryInvalidField(t *testing.T) {
+	account := Account{
+		Type: "account",
+		Attributes: &AccountAttributes{
+			Country:                     "UK",
+			BaseCurrency:                "XYZ",
+			Bic:                         "NWBK22",
+			Iban:                        "GB12NWBK40030041426819",
+			AccountClassification:       "Corporate",
+			AlternativeBankAccountNames: []string{"a", "", "c", "d"},
+		},
+	}
+
+	err := account.Validate()
+
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Expected ValidationErrors but got %v", err)
+	}
+
+	expectedFields := []string{
+		"type",
+		"id",
+		"organisation_id",
+		"attributes.country",
+		"attributes.base_currency",
+		"attributes.bic",
+		"attributes.iban",
+		"attributes.account_classification",
+		"attributes.alternative_bank_account_names",
+		"attributes.alternative_bank_account_names[1]",
+	}
+	if len(validationErrors) != len(expectedFields) {
+		t.Fatalf("Expected %d errors but got %d: %v", len(expectedFields), len(validationErrors), err)
+	}
+	for i, field := range expectedFields {
+		if validationErrors[i].Field != field {
+			t.Errorf("Expected error %d on %s but got %s", i, field, validationErrors[i].Field)
+		}
+	}
+}
+
+func TestValidateNamesAndIdentification(t *testing.T) {
+	account := validAccount()
+	account.Attributes.Name = []string{"Samantha Holder", "", "c", "d", strings.Repeat("e", 141)}
+	account.Attributes.AlternativeNames = []string{"Sam Holder"}
+	account.Attributes.NameMatchingStatus = "unknown"
+	account.Attributes.PrivateIdentification = &PrivateIdentification{BirthDate: "17/01/2017", BirthCountry: "GB", Country: "UK"}
+	account.Attributes.OrganisationIdentification = &OrganisationIdentification{
+		Country: "GB",
+		Actors:  []OrganisationActor{{Name: []string{"Jeff Page"}, BirthDate: "1970-01-01", Residency: "XX"}},
+	}
+	account.Attributes.UserDefinedData = []UserDefinedData{{Key: "reference", Value: "1"}, {Value: "2"}}
+
+	err := account.Validate()
+
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Expected ValidationErrors but got %v", err)
+	}
+
+	expectedFields := []string{
+		"attributes.name",
+		"attributes.name[1]",
+		"attributes.name[4]",
+		"attributes.name_matching_status",
+		"attributes.private_identification.birth_date",
+		"attributes.private_identification.country",
+		"attributes.organisation_identification.actors[0].residency",
+		"attributes.user_defined_data[1].key",
+	}
+	if len(validationErrors) != len(expectedFields) {
+		t.Fatalf("Expected %d errors but got %d: %v", len(expectedFields), len(validationErrors), err)
+	}
+	for i, field := range expectedFields {
+		if validationErrors[i].Field != field {
+			t.Errorf("Expected error %d on %s but got %s", i, field, validationErrors[i].Field)
+		}
+	}
+}
+
+func TestValidateMissingAttributes(t *testing.T) {
+	account := validAccount()
+	account.Attributes = nil
+
+	err := account.Validate()
+	if err == nil || err.Error() != "invalid account: attributes: is required" {
+		t.Errorf("Expected missing attributes error but got %v", err)
+	}
+}
+
+func TestValidateCountryIsRequired(t *testing.T) {
+	attributes := AccountAttributes{}
+
+	err := attributes.Validate()
+	if err == nil || err.Error() != "invalid account: country: is required" {
+		t.Errorf("Expected missing country error but got %v", err)
+	}
+}
+
+func TestValidateBic(t *testing.T) {
+	tests := []struct {
+		bic   string
+		valid bool
+	}{
+		{"NWBKGB22", true},
+		{"NWBKGB22XXX", true},
+		{"DEUTDEFF500", true},
+		{"NWBKGB2", false},
+		{"NWBKGB22XX", false},
+		{"nwbkgb22", false},
+		{"NW1KGB22", false},
+	}
+
+	for _, test := range tests {
+		attributes := *validAccount().Attributes
+		attributes.Bic = test.bic
+		err := attributes.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("BIC %s: expected valid %t but got %v", test.bic, test.valid, err)
+		}
+	}
+}
+
+func TestValidateIban(t *testing.T) {
+	tests := []struct {
+		iban    string
+		message string
+	}{
+		{"GB16NWBK40030041426819", ""},
+		{"GB16 NWBK 4003 0041 4268 19", ""},
+		{"SA0380000000608010167519", ""},
+		{"GB12NWBK40030041426819", "is not a valid IBAN: invalid checksum"},
+		{"GB16NWBK4003004142681", "is not a valid IBAN: invalid length"},
+		{"GB16NWBK4003004142681!", "is not a valid IBAN: invalid format"},
+		{"SA0480000000608010167519", "is not a valid IBAN"},
+		{"DE89370400440532013000", "must be an IBAN of country GB"},
+	}
+
+	for _, test := range tests {
+		attributes := *validAccount().Attributes
+		attributes.Iban = test.iban
+
+		err := attributes.Validate()
+		if test.message == "" {
+			if err != nil {
+				t.Errorf("IBAN %q: expected no error but got %v", test.iban, err)
+			}
+			continue
+		}
+		expected := "invalid account: iban: " + test.message
+		if err == nil || err.Error() != expected {
+			t.Errorf("IBAN %q: expected %v but got %v", test.iban, expected, err)
+		}
+	}
+}
+
+func TestValidateIbanMatchesAttributes(t *testing.T) {
+	attributes := *validAccount().Attributes
+	attributes.AccountNumber = "41426819"
+
+	if err := attributes.Validate(); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	attributes.AccountNumber = "41426820"
+	err := attributes.Validate()
+	expected := "invalid account: iban: does not match the bank_id and account_number, expected GB86NWBK40030041426820"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %v but got %v", expected, err)
+	}
+}
+
+func TestFillIban(t *testing.T) {
+	attributes := AccountAttributes{Country: "GB", BankID: "400300", Bic: "NWBKGB22", AccountNumber: "41426819"}
+
+	if err := attributes.FillIban(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if attributes.Iban != "GB16NWBK40030041426819" {
+		t.Errorf("Unexpected IBAN %v", attributes.Iban)
+	}
+
+	attributes = AccountAttributes{Country: "US", BankID: "123456789", AccountNumber: "41426819"}
+	if err := attributes.FillIban(); err == nil || attributes.Iban != "" {
+		t.Errorf("Expected an error for a country without IBAN, got %v", err)
+	}
+}
+
+func TestValidateCountryRules(t *testing.T) {
+	account := validAccount()
+	account.Attributes.BankID = "40030"
+	account.Attributes.BankIDCode = "DEBLZ"
+	account.Attributes.AccountNumber = "4142681"
+
+	err := account.Validate()
+
+	expected := "invalid account: " +
+		"attributes.bank_id: must be 6 digits for country GB; " +
+		"attributes.bank_id_code: must be GBDSC for country GB; " +
+		"attributes.account_number: must be 8 digits for country GB"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Unexpected error\ngot      %v\nexpected %v", err, expected)
+	}
+}
+
+func TestValidateCountryRulesNotSupportedField(t *testing.T) {
+	attributes := AccountAttributes{Country: "NL", Bic: "ABNANL2A", BankID: "1234"}
+
+	err := attributes.Validate()
+	if err == nil || err.Error() != "invalid account: bank_id: is not supported for country NL" {
+		t.Errorf("Expected bank_id not supported but got %v", err)
+	}
+}
+
+func TestIsoCodes(t *testing.T) {
+	if !IsCountryCode("GB") || IsCountryCode("UK") || IsCountryCode("gb") {
+		t.Error("Unexpected result for country codes")
+	}
+	if !IsCurrencyCode("EUR") || IsCurrencyCode("EU") || IsCurrencyCode("eur") {
+		t.Error("Unexpected result for currency codes")
+	}
+}